@@ -5,73 +5,91 @@ import (
 	"slices"
 )
 
-type lineKind uint8
+type LineKind uint8
 
 const (
-	lineKindRow lineKind = iota
-	lineKindColumn
+	LineKindRow LineKind = iota
+	LineKindColumn
 )
 
-func (kind lineKind) String() string {
+func (kind LineKind) String() string {
 	switch kind {
-	case lineKindRow:
+	case LineKindRow:
 		return "Row"
-	case lineKindColumn:
+	case LineKindColumn:
 		return "Col"
 	default:
-		panic("invalid lineKind")
+		panic("invalid LineKind")
 	}
 }
 
-type lineRef struct {
-	kind  lineKind
-	index int
+type LineRef struct {
+	Kind  LineKind
+	Index int
 }
 
-func (ref lineRef) String() string {
-	return fmt.Sprintf("%s[%d]", ref.kind, ref.index)
+func (ref LineRef) String() string {
+	return fmt.Sprintf("%s[%d]", ref.Kind, ref.Index)
 }
 
-type lineView struct {
+// Rule に渡す1本の行・列。Rule は Cells と Hints を書き換えてはならない
+type Line struct {
 	Cells []Cell
 	Hints []int
+	// Hints と同じ長さの各ブロックの色。nil なら全て黒
+	Colors []Color
 }
 
-func (line lineView) IsFilled() bool {
+func (line Line) IsFilled() bool {
 	return slices.Index(line.Cells, CellUndetermined) == -1
 }
 
+// 現在のセルのままヒントを満たす配置が存在するか
+func (line Line) IsSolvable() bool {
+	return suffixPlaceable(line.Cells, line.Hints, line.Colors)[0][0]
+}
+
+func (line Line) IsMonochrome() bool {
+	return isMonochrome(line.Colors)
+}
+
+// 盤面の格納方法。Board と bitBoard が実装する
+type grid interface {
+	GetRows() int
+	GetColumns() int
+	at(row, col int) Cell
+	set(row, col int, c Cell)
+	// 行・列のセルを dst に上書きして返す。dst の容量が足りなければ確保し直す
+	line(ref LineRef, dst []Cell) []Cell
+	setLine(ref LineRef, cells []Cell)
+	cloneGrid() grid
+}
+
+// grid の内容を Board に写す
+func toBoard(g grid) Board {
+	board := newBoard(g.GetRows(), g.GetColumns())
+	for i := range board {
+		board[i] = g.line(LineRef{LineKindRow, i}, board[i])
+	}
+	return board
+}
+
 type lineAccessor struct {
-	board *Board
-	ref   lineRef
+	grid grid
+	ref  LineRef
 }
 
 func (acc lineAccessor) Cells() []Cell {
-	switch acc.ref.kind {
-	case lineKindRow:
-		return slices.Clone((*acc.board)[acc.ref.index])
-	case lineKindColumn:
-		cells := make([]Cell, acc.board.GetRows())
-		for i := range *acc.board {
-			cells[i] = (*acc.board)[i][acc.ref.index]
-		}
-		return cells
-	default:
-		panic("invalid linekind accessor")
-	}
+	return acc.grid.line(acc.ref, nil)
+}
+
+// Cells と同じだが、dst の領域を使い回す
+func (acc lineAccessor) CellsInto(dst []Cell) []Cell {
+	return acc.grid.line(acc.ref, dst)
 }
 
 func (acc lineAccessor) Update(cells []Cell) {
-	switch acc.ref.kind {
-	case lineKindRow:
-		copy((*acc.board)[acc.ref.index], cells)
-	case lineKindColumn:
-		for i := range cells {
-			(*acc.board)[i][acc.ref.index] = cells[i]
-		}
-	default:
-		panic("invalid linekind accessor")
-	}
+	acc.grid.setLine(acc.ref, cells)
 }
 
-func (acc lineAccessor) Ref() lineRef { return acc.ref }
+func (acc lineAccessor) Ref() LineRef { return acc.ref }
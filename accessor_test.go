@@ -0,0 +1,108 @@
+package picrosssolver
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestBitBoardMatchesBoard(t *testing.T) {
+	const height, width = 70, 130
+	board := newBoard(height, width)
+	bits := newBitBoard(height, width)
+	r := rand.New(rand.NewPCG(1, 2))
+	values := []Cell{CellUndetermined, CellWhite, CellBlack}
+
+	for range 2000 {
+		switch r.IntN(3) {
+		case 0:
+			row, col, c := r.IntN(height), r.IntN(width), values[r.IntN(3)]
+			board.set(row, col, c)
+			bits.set(row, col, c)
+		case 1:
+			ref := LineRef{LineKindRow, r.IntN(height)}
+			cells := make([]Cell, width)
+			for i := range cells {
+				cells[i] = values[r.IntN(3)]
+			}
+			board.setLine(ref, cells)
+			bits.setLine(ref, cells)
+		case 2:
+			ref := LineRef{LineKindColumn, r.IntN(width)}
+			cells := make([]Cell, height)
+			for i := range cells {
+				cells[i] = values[r.IntN(3)]
+			}
+			board.setLine(ref, cells)
+			bits.setLine(ref, cells)
+		}
+	}
+
+	for i := range height {
+		ref := LineRef{LineKindRow, i}
+		if !slices.Equal(board.line(ref, nil), bits.line(ref, nil)) {
+			t.Fatalf("%s differs", ref)
+		}
+	}
+	for j := range width {
+		ref := LineRef{LineKindColumn, j}
+		if !slices.Equal(board.line(ref, nil), bits.line(ref, nil)) {
+			t.Fatalf("%s differs", ref)
+		}
+	}
+
+	clone := bits.cloneGrid()
+	clone.set(0, 0, CellBlack)
+	bits.set(0, 0, CellWhite)
+	if clone.at(0, 0) != CellBlack || bits.at(0, 0) != CellWhite {
+		t.Errorf("cloneGrid shares state")
+	}
+}
+
+// 全ての行と列を読む。write なら読んだ列をそのまま書き戻す
+func benchmarkGridLines(b *testing.B, g grid, write bool) {
+	buf := make([]Cell, 0, max(g.GetRows(), g.GetColumns()))
+	for b.Loop() {
+		for i := range g.GetRows() {
+			ref := LineRef{LineKindRow, i}
+			buf = g.line(ref, buf)
+			if write {
+				g.setLine(ref, buf)
+			}
+		}
+		for j := range g.GetColumns() {
+			ref := LineRef{LineKindColumn, j}
+			buf = g.line(ref, buf)
+			if write {
+				g.setLine(ref, buf)
+			}
+		}
+	}
+}
+
+func randomGrid(g grid) grid {
+	r := rand.New(rand.NewPCG(1, 2))
+	values := []Cell{CellUndetermined, CellWhite, CellBlack}
+	for i := range g.GetRows() {
+		for j := range g.GetColumns() {
+			g.set(i, j, values[r.IntN(3)])
+		}
+	}
+	return g
+}
+
+func BenchmarkBoardReadLines(b *testing.B) {
+	benchmarkGridLines(b, randomGrid(newBoard(100, 100)), false)
+}
+
+func BenchmarkBitBoardReadLines(b *testing.B) {
+	benchmarkGridLines(b, randomGrid(newBitBoard(100, 100)), false)
+}
+
+func BenchmarkBoardLines(b *testing.B) {
+	benchmarkGridLines(b, randomGrid(newBoard(100, 100)), true)
+}
+
+func BenchmarkBitBoardLines(b *testing.B) {
+	benchmarkGridLines(b, randomGrid(newBitBoard(100, 100)), true)
+}
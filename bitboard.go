@@ -0,0 +1,182 @@
+package picrosssolver
+
+import (
+	"math/bits"
+	"slices"
+)
+
+// 1本の行・列の黒と白を bit 列で持つ
+type bitLine struct {
+	black []uint64
+	white []uint64
+}
+
+func newBitLine(length int) bitLine {
+	words := (length + 63) / 64
+	return bitLine{make([]uint64, words), make([]uint64, words)}
+}
+
+func (l bitLine) at(i int) Cell {
+	word, bit := i/64, uint64(1)<<(i%64)
+	switch {
+	case l.black[word]&bit != 0:
+		return CellBlack
+	case l.white[word]&bit != 0:
+		return CellWhite
+	default:
+		return CellUndetermined
+	}
+}
+
+func (l bitLine) set(i int, c Cell) {
+	word, bit := i/64, uint64(1)<<(i%64)
+	l.black[word] &^= bit
+	l.white[word] &^= bit
+	switch c {
+	case CellBlack:
+		l.black[word] |= bit
+	case CellWhite:
+		l.white[word] |= bit
+	case CellUndetermined:
+	default:
+		panic("bitBoard は多色に未対応")
+	}
+}
+
+// 白は 1、黒は 2 なので、各セルの下位 bit が白、その上の bit が黒になる
+const (
+	lowBits   = 0x0101010101010101
+	colorBits = 0xfcfcfcfcfcfcfcfc
+	// 各バイトの最下位 bit を上位バイトへ集める
+	gatherBits = 0x0102040810204080
+)
+
+// 64 個までのセルを黒と白の bit 列に詰める。8 セルずつまとめて処理する
+func packCells(cells []Cell) (black, white uint64) {
+	k := 0
+	for ; k+8 <= len(cells); k += 8 {
+		c := cells[k : k+8 : k+8]
+		x := uint64(c[0]) | uint64(c[1])<<8 | uint64(c[2])<<16 | uint64(c[3])<<24 |
+			uint64(c[4])<<32 | uint64(c[5])<<40 | uint64(c[6])<<48 | uint64(c[7])<<56
+		if x&colorBits != 0 || x&(x>>1)&lowBits != 0 {
+			panic("bitBoard は多色に未対応")
+		}
+		white |= (x & lowBits * gatherBits >> 56) << k
+		black |= (x >> 1 & lowBits * gatherBits >> 56) << k
+	}
+	for ; k < len(cells); k++ {
+		c := cells[k]
+		if c&^3 != 0 || c == 3 {
+			panic("bitBoard は多色に未対応")
+		}
+		white |= uint64(c&1) << k
+		black |= uint64(c>>1) << k
+	}
+	return black, white
+}
+
+// spreadBits[b]: b の各 bit を対応するバイトの最下位 bit に広げた値
+var spreadBits = func() (table [256]uint64) {
+	for b := range table {
+		for k := range 8 {
+			table[b] |= uint64(b>>k&1) << (8 * k)
+		}
+	}
+	return table
+}()
+
+// packCells の逆。黒と白の bit 列を cells に書き出す
+func unpackCells(cells []Cell, black, white uint64) {
+	k := 0
+	for ; k+8 <= len(cells); k += 8 {
+		x := spreadBits[white>>k&0xff] | spreadBits[black>>k&0xff]<<1
+		c := cells[k : k+8 : k+8]
+		c[0], c[1], c[2], c[3] = Cell(x), Cell(x>>8), Cell(x>>16), Cell(x>>24)
+		c[4], c[5], c[6], c[7] = Cell(x>>32), Cell(x>>40), Cell(x>>48), Cell(x>>56)
+	}
+	for ; k < len(cells); k++ {
+		cells[k] = Cell(white>>k&1 | black>>k&1<<1)
+	}
+}
+
+func (l bitLine) clone() bitLine {
+	return bitLine{slices.Clone(l.black), slices.Clone(l.white)}
+}
+
+// 白黒の盤面を行ごと・列ごとの bit 列で持つ。列の読み書きも行と同じ速さでできる
+type bitBoard struct {
+	height, width int
+	rows          []bitLine
+	cols          []bitLine
+}
+
+func newBitBoard(height, width int) *bitBoard {
+	b := &bitBoard{height, width, make([]bitLine, height), make([]bitLine, width)}
+	for i := range b.rows {
+		b.rows[i] = newBitLine(width)
+	}
+	for j := range b.cols {
+		b.cols[j] = newBitLine(height)
+	}
+	return b
+}
+
+func (b *bitBoard) GetRows() int    { return b.height }
+func (b *bitBoard) GetColumns() int { return b.width }
+
+func (b *bitBoard) at(row, col int) Cell {
+	return b.rows[row].at(col)
+}
+
+func (b *bitBoard) set(row, col int, c Cell) {
+	b.rows[row].set(col, c)
+	b.cols[col].set(row, c)
+}
+
+func (b *bitBoard) lineOf(ref LineRef) (bitLine, int) {
+	switch ref.Kind {
+	case LineKindRow:
+		return b.rows[ref.Index], b.width
+	case LineKindColumn:
+		return b.cols[ref.Index], b.height
+	default:
+		panic("invalid linekind accessor")
+	}
+}
+
+func (b *bitBoard) line(ref LineRef, dst []Cell) []Cell {
+	l, length := b.lineOf(ref)
+	dst = slices.Grow(dst[:0], length)[:length]
+	for w := range l.black {
+		unpackCells(dst[w*64:min(length, (w+1)*64)], l.black[w], l.white[w])
+	}
+	return dst
+}
+
+// bit 列をその場で書き換え、値が変わったセルだけ交差する行・列の bit を更新する
+func (b *bitBoard) setLine(ref LineRef, cells []Cell) {
+	l, _ := b.lineOf(ref)
+	for w := range l.black {
+		black, white := packCells(cells[w*64 : min(len(cells), (w+1)*64)])
+		for diff := (l.black[w] ^ black) | (l.white[w] ^ white); diff != 0; diff &= diff - 1 {
+			i := w*64 + bits.TrailingZeros64(diff)
+			if ref.Kind == LineKindRow {
+				b.cols[i].set(ref.Index, cells[i])
+			} else {
+				b.rows[i].set(ref.Index, cells[i])
+			}
+		}
+		l.black[w], l.white[w] = black, white
+	}
+}
+
+func (b *bitBoard) cloneGrid() grid {
+	clone := &bitBoard{b.height, b.width, make([]bitLine, b.height), make([]bitLine, b.width)}
+	for i := range b.rows {
+		clone.rows[i] = b.rows[i].clone()
+	}
+	for j := range b.cols {
+		clone.cols[j] = b.cols[j].clone()
+	}
+	return clone
+}
@@ -0,0 +1,121 @@
+package picrosssolver
+
+import (
+	"container/list"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 行・列の推論結果のキャッシュの統計
+type CacheStats struct {
+	Hits    int
+	Misses  int
+	Entries int
+}
+
+type cacheEntry struct {
+	key  string
+	deds []Deduction
+}
+
+// ヒントとセルが同じ行・列の DeduceLine の結果を使い回す LRU キャッシュ
+type lineCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	order   *list.List
+	hits    int
+	misses  int
+}
+
+func newLineCache(size int) *lineCache {
+	return &lineCache{size: size, entries: map[string]*list.Element{}, order: list.New()}
+}
+
+// ルールの型と値の並びを前置し、ルール構成の異なるソルバーと結果が混ざらないようにする。
+// 名前だけでは、同じ名前で振る舞いの異なるルールを見分けられない
+func lineCacheKey(rules []Rule, line Line) string {
+	var b strings.Builder
+	for _, rule := range rules {
+		fmt.Fprintf(&b, "%T%v,", rule, rule)
+	}
+	b.WriteByte('|')
+	for _, h := range line.Hints {
+		b.WriteString(strconv.Itoa(h))
+		b.WriteByte(',')
+	}
+	b.WriteByte('|')
+	for _, c := range line.Colors {
+		b.WriteByte(byte(c))
+	}
+	b.WriteByte('|')
+	for _, c := range line.Cells {
+		b.WriteByte(byte(c))
+	}
+	return b.String()
+}
+
+func (c *lineCache) get(key string) ([]Deduction, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.order.MoveToFront(elem)
+	return elem.Value.(*cacheEntry).deds, true
+}
+
+func (c *lineCache) put(key string, deds []Deduction) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key, deds})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+func (c *lineCache) stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{c.hits, c.misses, c.order.Len()}
+}
+
+// キャッシュがあれば経由して DeduceLine を呼ぶ。キャッシュの推論は ref だけ差し替えて返す
+func (s Solver) deduceLine(line Line, ref LineRef) []Deduction {
+	if s.cache == nil {
+		return s.deducer.DeduceLine(line, ref)
+	}
+	key := lineCacheKey(s.deducer.rules, line)
+	if cached, ok := s.cache.get(key); ok {
+		deds := make([]Deduction, len(cached))
+		for i, ded := range cached {
+			ded.lineRef = ref
+			deds[i] = ded
+		}
+		return deds
+	}
+	deds := s.deducer.DeduceLine(line, ref)
+	s.cache.put(key, deds)
+	return deds
+}
+
+// WithLineCache を指定していなければゼロ値を返す
+func (s Solver) CacheStats() CacheStats {
+	if s.cache == nil {
+		return CacheStats{}
+	}
+	return s.cache.stats()
+}
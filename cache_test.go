@@ -0,0 +1,66 @@
+package picrosssolver
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestLineCacheEviction(t *testing.T) {
+	cache := newLineCache(2)
+	cache.put("a", nil)
+	cache.put("b", nil)
+	cache.get("a")
+	cache.put("c", nil)
+
+	if _, ok := cache.get("b"); ok {
+		t.Errorf("expected b to be evicted")
+	}
+	if _, ok := cache.get("a"); !ok {
+		t.Errorf("expected a to remain")
+	}
+	if _, ok := cache.get("c"); !ok {
+		t.Errorf("expected c to remain")
+	}
+
+	expected := CacheStats{Hits: 3, Misses: 1, Entries: 2}
+	if got := cache.stats(); got != expected {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+}
+
+// 名前は同じで、設定によって振る舞いの変わるルール
+type fillRule struct {
+	cell Cell
+}
+
+func (r fillRule) Name() string { return "FillRule" }
+
+func (r fillRule) Deduce(line Line) []Cell {
+	cells := slices.Clone(line.Cells)
+	for i := range cells {
+		cells[i] = r.cell
+	}
+	return cells
+}
+
+func TestLineCacheKeyIncludesRules(t *testing.T) {
+	tests := []struct {
+		a, b Rule
+	}{
+		{OverlapFillRule{}, ExhaustivePlacementRule{}},
+		{fillRule{W}, fillRule{B}},
+	}
+	line := Line{Cells: []Cell{U, U, U}, Hints: []int{2}}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("case%d", i), func(t *testing.T) {
+			a := lineCacheKey([]Rule{tt.a}, line)
+			b := lineCacheKey([]Rule{tt.b}, line)
+
+			if a == b {
+				t.Errorf("expected different keys for different rules, got %q", a)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	picrosssolver "github.com/inahym196/picross-solver"
+)
+
+// JSON 形式のパズル
+type jsonPuzzle struct {
+	RowHints [][]int `json:"rowHints"`
+	ColHints [][]int `json:"colHints"`
+}
+
+// "1-2 3" 形式の1行を行・列ごとのヒントにする
+func parseDashHints(s string) ([][]int, error) {
+	fields := strings.Fields(s)
+	hints := make([][]int, 0, len(fields))
+	for _, f := range fields {
+		parts := strings.Split(f, "-")
+		line := make([]int, 0, len(parts))
+		for _, p := range parts {
+			n, err := strconv.Atoi(p)
+			if err != nil {
+				return nil, fmt.Errorf("ヒントが数値ではない: %q", f)
+			}
+			line = append(line, n)
+		}
+		hints = append(hints, line)
+	}
+	return hints, nil
+}
+
+// 1行目に行のヒント、2行目に列のヒントを書いたテキスト。空行と # で始まる行は読み飛ばす
+func readDashText(r io.Reader) (*picrosssolver.Game, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(lines) != 2 {
+		return nil, fmt.Errorf("行と列のヒントの2行が必要: %d行", len(lines))
+	}
+	rowHints, err := parseDashHints(lines[0])
+	if err != nil {
+		return nil, fmt.Errorf("rows: %w", err)
+	}
+	colHints, err := parseDashHints(lines[1])
+	if err != nil {
+		return nil, fmt.Errorf("columns: %w", err)
+	}
+	return picrosssolver.NewGame(rowHints, colHints)
+}
+
+func readJSON(r io.Reader) (*picrosssolver.Game, error) {
+	var p jsonPuzzle
+	if err := json.NewDecoder(r).Decode(&p); err != nil {
+		return nil, err
+	}
+	return picrosssolver.NewGame(p.RowHints, p.ColHints)
+}
+
+// 拡張子から形式を推定する。不明なら中身の先頭を見る
+func detectFormat(name string, data []byte) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		return "json"
+	case ".non":
+		return "non"
+	case ".xml", ".pbn":
+		return "xml"
+	case ".txt":
+		return "text"
+	}
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		return "json"
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		return "xml"
+	case bytes.Contains(data, []byte("\nrows")) || bytes.HasPrefix(trimmed, []byte("rows")):
+		return "non"
+	default:
+		return "text"
+	}
+}
+
+func readPuzzle(format string, data []byte) (*picrosssolver.Game, error) {
+	r := bytes.NewReader(data)
+	switch format {
+	case "text":
+		return readDashText(r)
+	case "json":
+		return readJSON(r)
+	case "non":
+		game, _, err := picrosssolver.ReadNon(r)
+		return game, err
+	case "xml":
+		game, _, err := picrosssolver.ReadWebpbn(r)
+		return game, err
+	default:
+		return nil, errors.New("未対応の形式: " + format)
+	}
+}
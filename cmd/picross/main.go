@@ -0,0 +1,35 @@
+// picross はパズルファイルを解くコマンド
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: picross <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	fmt.Fprintln(w, "  solve   パズルファイルを解いて盤面を表示する")
+	fmt.Fprintln(w, "  serve   HTTP の JSON API でパズルを解く (途中経過は /solve/stream)")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage(os.Stderr)
+		os.Exit(exitUsage)
+	}
+	switch os.Args[1] {
+	case "solve":
+		os.Exit(runSolve(os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
+	case "serve":
+		os.Exit(runServe(os.Args[2:], os.Stderr))
+	case "-h", "-help", "--help", "help":
+		usage(os.Stdout)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command: %s\n", os.Args[1])
+		usage(os.Stderr)
+		os.Exit(exitUsage)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/inahym196/picross-solver/server"
+)
+
+func runServe(args []string, stderr io.Writer) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	addr := fs.String("addr", "localhost:8080", "待ち受けるアドレス")
+	maxBody := fs.Int64("max-body", 1<<20, "リクエスト本文の上限のバイト数")
+	timeout := fs.Duration("timeout", 0, "1リクエストの処理時間の上限 (0 なら 10s)")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: picross serve [flags]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	h := server.New(server.Options{MaxBodyBytes: *maxBody, Timeout: *timeout})
+	fmt.Fprintf(stderr, "listening on %s\n", *addr)
+	if err := http.ListenAndServe(*addr, h); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+	return exitOK
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	picrosssolver "github.com/inahym196/picross-solver"
+	"github.com/inahym196/picross-solver/render"
+)
+
+const (
+	exitOK = iota
+	exitError
+	exitUsage
+	exitUnsolved
+)
+
+// フラグに合わせた Solver を作る
+func newSolver(hypothesis bool, workers int) picrosssolver.Solver {
+	opts := []picrosssolver.Option{picrosssolver.WithWorkers(workers)}
+	if hypothesis {
+		opts = append(opts, picrosssolver.WithHypothesis())
+	}
+	return picrosssolver.NewSolver(opts...)
+}
+
+func runSolve(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("solve", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	format := fs.String("format", "auto", "入力形式 (auto, text, json, non, xml)")
+	trace := fs.Bool("trace", false, "推論の履歴を表示する")
+	count := fs.Bool("count", false, "ApplyMany の反復回数を表示する")
+	colored := fs.Bool("color", false, "ヒント付きの色付きで盤面を表示し、最後の推論を強調する")
+	hypothesis := fs.Bool("hypothesis", false, "行・列のルールで進めなくなったら仮定を試す")
+	workers := fs.Int("workers", 1, "1パスの行・列を並行に評価するワーカーの数")
+	strict := fs.Bool("strict", false, "未確定のセルが残ったら終了コード 3 で終了する")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: picross solve [flags] <file|->")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	name := fs.Arg(0)
+	var data []byte
+	var err error
+	if name == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(name)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+	if *format == "auto" {
+		*format = detectFormat(name, data)
+	}
+	game, err := readPuzzle(*format, data)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", name, err)
+		return exitError
+	}
+
+	n, deds, err := newSolver(*hypothesis, *workers).ApplyMany(game)
+	if *trace {
+		for _, ded := range deds {
+			fmt.Fprintln(stdout, ded)
+		}
+	}
+	if *count {
+		fmt.Fprintf(stdout, "iterations: %d\n", n)
+	}
+	if *colored {
+		var opts render.Options
+		if len(deds) > 0 {
+			opts.Highlight = &deds[len(deds)-1]
+		}
+		if err := render.Terminal(stdout, game, opts); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+	} else {
+		for _, line := range game.PrintBoard() {
+			fmt.Fprintln(stdout, line)
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+	if *strict && !game.IsSolved() {
+		fmt.Fprintln(stderr, "未確定のセルが残っている")
+		return exitUnsolved
+	}
+	return exitOK
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRunSolve(t *testing.T) {
+	tests := []struct {
+		args     []string
+		input    string
+		code     int
+		expected string
+	}{
+		{[]string{"-"}, "# sample\n0 2\n1 1\n", exitOK, "__\n##\n"},
+		{[]string{"-format", "json", "-"}, `{"rowHints":[[1],[1]],"colHints":[[2],[0]]}`, exitOK, "#_\n#_\n"},
+		{[]string{"-count", "-"}, `{"rowHints":[[0],[2]],"colHints":[[1],[1]]}`, exitOK, "iterations: 1\n__\n##\n"},
+		{[]string{"-workers", "4", "-trace", "-"}, "0 2\n1 1\n", exitOK, "ZeroHintRule Row[0] [0] [U U] -> [W W]\nMinimumSpacingRule Row[1] [2] [U U] -> [B B]\n__\n##\n"},
+		{[]string{"-trace", "-"}, "0 2\n1 1\n", exitOK, "ZeroHintRule Row[0] [0] [U U] -> [W W]\nMinimumSpacingRule Row[1] [2] [U U] -> [B B]\n__\n##\n"},
+		{[]string{"-"}, "width 2\nheight 2\nrows\n2\n0\ncolumns\n1\n1\n", exitOK, "##\n__\n"},
+		{[]string{"-"}, "1 1\n1 1\n", exitOK, "??\n??\n"},
+		{[]string{"-strict", "-"}, "1 1\n1 1\n", exitUnsolved, "??\n??\n"},
+		{[]string{"-hypothesis", "-"}, "1 2 2-1 2-1 1-1\n1-1 3 2 1-2 1\n", exitOK, "#____\n__##_\n_##_#\n##_#_\n_#_#_\n"},
+		{[]string{"-color", "-"}, "0 2\n1 1\n", exitOK, "   1 1\n0 \x1b[38;5;255m██\x1b[0m\x1b[38;5;255m██\x1b[0m\n\x1b[1;33m2\x1b[0m \x1b[48;5;229m\x1b[38;5;232m▓▓\x1b[0m\x1b[48;5;229m\x1b[38;5;232m▓▓\x1b[0m\n"},
+		{[]string{"-"}, "1 x\n1 1\n", exitError, ""},
+		{[]string{}, "", exitUsage, ""},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("case%d", i), func(t *testing.T) {
+			var stdout, stderr strings.Builder
+
+			code := runSolve(tt.args, strings.NewReader(tt.input), &stdout, &stderr)
+
+			if code != tt.code {
+				t.Errorf("expected exit code %d, got %d (stderr: %s)", tt.code, code, stderr.String())
+			}
+			if stdout.String() != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, stdout.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package picrosssolver
+
+import "math/bits"
+
+// 塗り色の番号。0 は黒
+type Color uint8
+
+const ColorBlack Color = 0
+
+// Cell で表せる色の数
+const MaxColors = 64 - int(CellBlack)
+
+// 色 color で塗られたセル
+func ColorCell(color Color) Cell {
+	return CellBlack + Cell(color)
+}
+
+// 色付きのヒント
+type ColorHint struct {
+	Length int
+	Color  Color
+}
+
+// セルが取りうる値の集合。Cell の値をビット位置とする
+type cellSet uint64
+
+func cellBit(c Cell) cellSet {
+	return 1 << c
+}
+
+func (s cellSet) has(c Cell) bool {
+	return s&cellBit(c) != 0
+}
+
+// 値がひとつに決まっていればそれを返す
+func (s cellSet) single() (Cell, bool) {
+	if bits.OnesCount64(uint64(s)) != 1 {
+		return CellUndetermined, false
+	}
+	return Cell(bits.TrailingZeros64(uint64(s))), true
+}
+
+func splitColorHints(colorHints [][]ColorHint) ([][]int, [][]Color) {
+	hints := make([][]int, len(colorHints))
+	colors := make([][]Color, len(colorHints))
+	for i, line := range colorHints {
+		hints[i] = make([]int, len(line))
+		colors[i] = make([]Color, len(line))
+		for j, h := range line {
+			hints[i][j] = h.Length
+			colors[i][j] = h.Color
+		}
+	}
+	return hints, colors
+}
+
+// nil は全て黒
+func isMonochrome(colors []Color) bool {
+	for _, c := range colors {
+		if c != ColorBlack {
+			return false
+		}
+	}
+	return true
+}
@@ -5,55 +5,124 @@ import (
 	"slices"
 )
 
-type deduction struct {
+// ひとつのルールが1本の行・列に対して行った推論
+type Deduction struct {
 	ruleName string
 	hints    []int
-	lineRef  lineRef
+	colors   []Color
+	lineRef  LineRef
 	before   []Cell
 	after    []Cell
+	// HypothesisRule の推論だけが持つ
+	probe *Probe
 }
 
-func (deduction deduction) String() string {
-	return fmt.Sprintf("%s %s %v %v -> %v", deduction.ruleName, deduction.lineRef, deduction.hints, deduction.before, deduction.after)
+// HypothesisRule が値を仮定したセルと、矛盾しなかった仮定の値。
+// 推論はこれらの仮定のすべてで同じ値になったセルを確定したもの
+type Probe struct {
+	Row, Col int
+	// ひとつだけなら、仮定したセルはその値に決まる
+	Survivors []Cell
+}
+
+// "(<Row>,<Col>) <Survivors>" の形式。例: "(0,1) [B]"
+func (p Probe) String() string {
+	return fmt.Sprintf("(%d,%d) %v", p.Row, p.Col, p.Survivors)
+}
+
+func (deduction Deduction) Rule() string   { return deduction.ruleName }
+func (deduction Deduction) Line() LineRef  { return deduction.lineRef }
+func (deduction Deduction) Kind() LineKind { return deduction.lineRef.Kind }
+func (deduction Deduction) Index() int     { return deduction.lineRef.Index }
+func (deduction Deduction) Hints() []int   { return slices.Clone(deduction.hints) }
+func (deduction Deduction) Before() []Cell { return slices.Clone(deduction.before) }
+func (deduction Deduction) After() []Cell  { return slices.Clone(deduction.after) }
+
+// HypothesisRule の推論なら、それを導いた仮定を返す
+func (deduction Deduction) Probe() (Probe, bool) {
+	if deduction.probe == nil {
+		return Probe{}, false
+	}
+	return Probe{deduction.probe.Row, deduction.probe.Col, slices.Clone(deduction.probe.Survivors)}, true
+}
+
+// 黒だけの行・列なら nil
+func (deduction Deduction) Colors() []Color { return slices.Clone(deduction.colors) }
+
+// 推論で値が変わったセルの、行・列内での位置
+func (deduction Deduction) ChangedCells() []int {
+	var changed []int
+	for i := range deduction.before {
+		if deduction.before[i] != deduction.after[i] {
+			changed = append(changed, i)
+		}
+	}
+	return changed
+}
+
+// "<Rule> <Line> <Hints> <Before> -> <After>" の形式。例: "OverlapFillRule Row[0] [2] [U U U] -> [U B U]"。
+// HypothesisRule の推論は後ろに " probe <Probe>" が付く
+func (deduction Deduction) String() string {
+	s := fmt.Sprintf("%s %s %v %v -> %v", deduction.ruleName, deduction.lineRef, deduction.hints, deduction.before, deduction.after)
+	if deduction.probe != nil {
+		s += " probe " + deduction.probe.String()
+	}
+	return s
 }
 
 type deducer struct {
 	rules []Rule
 }
 
-func newDeducer() deducer {
-	return deducer{
-		[]Rule{
-			ZeroHintRule{},
-			MinimumSpacingRule{},
-			OverlapFillRule{},
-			OverlapExpansionRule{},
-			EdgeExpansionRule{},
-			BlockSatisfiedRule{},
-			PruneImpossibleSegmentRule{},
-			FillRemainingWhiteRule{},
-		},
+// NewSolver が既定で使うルールを適用順に返す
+func DefaultRules() []Rule {
+	return []Rule{
+		ZeroHintRule{},
+		MinimumSpacingRule{},
+		OverlapFillRule{},
+		OverlapExpansionRule{},
+		EdgeExpansionRule{},
+		BlockSatisfiedRule{},
+		PruneImpossibleSegmentRule{},
+		FillRemainingWhiteRule{},
 	}
 }
 
-func (d deducer) DeduceLine(line lineView, ref lineRef) (deds []deduction) {
+func newDeducer() deducer {
+	return deducer{DefaultRules()}
+}
+
+// 多色の行・列は配置の全探索だけで推論する。他のルールは黒だけを前提にしているので、
+// WithRules などの指定にかかわらずこれを使う
+var colorRules = []Rule{ExhaustivePlacementRule{}}
+
+func (d deducer) DeduceLine(line Line, ref LineRef) (deds []Deduction) {
 	current := line
 
-	for _, rule := range d.rules {
+	rules := d.rules
+	if !line.IsMonochrome() {
+		rules = colorRules
+	}
+	for _, rule := range rules {
 		if current.IsFilled() {
 			return deds
 		}
 
-		before := slices.Clone(current.Cells)
+		// Rule は Cells を書き換えないので、推論できたときだけ複製する
 		updated := rule.Deduce(current)
-
-		if updated == nil || slices.Equal(before, updated) {
+		if updated == nil || slices.Equal(current.Cells, updated) {
 			continue
 		}
+		before := current.Cells
+		if len(deds) == 0 {
+			// 最初の before は呼び出し側の領域なので、推論に残すには複製が要る
+			before = slices.Clone(before)
+		}
 
-		deds = append(deds, deduction{
+		deds = append(deds, Deduction{
 			ruleName: rule.Name(),
 			hints:    current.Hints,
+			colors:   current.Colors,
 			lineRef:  ref,
 			before:   before,
 			after:    updated,
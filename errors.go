@@ -0,0 +1,39 @@
+package picrosssolver
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrContradiction = errors.New("ヒントを満たす配置が存在しない")
+
+var (
+	ErrIterationLimit = errors.New("反復回数の上限に達した")
+	ErrDeductionLimit = errors.New("推論の件数の上限に達した")
+)
+
+var (
+	ErrNoHints            = errors.New("rowHints,colHintsは1より大きい必要がある")
+	ErrEmptyLineHints     = errors.New("ヒントが空")
+	ErrNegativeHint       = errors.New("ヒントは0以上である必要がある")
+	ErrInvalidZeroHint    = errors.New("0は単独でのみ指定できる")
+	ErrHintsTooLong       = errors.New("ヒントの最小配置が盤面に収まらない")
+	ErrBlackCountMismatch = errors.New("行と列の黒セル数の合計が一致しない")
+	ErrColorCountMismatch = errors.New("行と列の色セル数の合計が一致しない")
+	ErrTooManyColors      = errors.New("色の番号が大きすぎる")
+)
+
+// 行・列のセルがヒントと矛盾していることを表す
+type ContradictionError struct {
+	Line  LineRef
+	Hints []int
+	Cells []Cell
+}
+
+func (e *ContradictionError) Error() string {
+	return fmt.Sprintf("%s %v %v: %s", e.Line, e.Hints, e.Cells, ErrContradiction)
+}
+
+func (e *ContradictionError) Unwrap() error {
+	return ErrContradiction
+}
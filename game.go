@@ -1,7 +1,8 @@
 package picrosssolver
 
 import (
-	"errors"
+	"fmt"
+	"slices"
 	"strings"
 )
 
@@ -22,10 +23,20 @@ func (c Cell) String() string {
 	case CellWhite:
 		return "W"
 	default:
-		panic("invalid cell")
+		return fmt.Sprintf("C%d", c.Color())
 	}
 }
 
+// 色で塗られたセルか
+func (c Cell) IsColored() bool {
+	return c >= CellBlack
+}
+
+// 塗られたセルの色。IsColored でなければ意味を持たない
+func (c Cell) Color() Color {
+	return Color(c - CellBlack)
+}
+
 type Board [][]Cell
 
 func newBoard(height, width int) Board {
@@ -36,6 +47,100 @@ func newBoard(height, width int) Board {
 	return board
 }
 
+func (b Board) clone() Board {
+	board := make(Board, len(b))
+	for i := range b {
+		board[i] = slices.Clone(b[i])
+	}
+	return board
+}
+
+// 未確定のセルが残っていないか
+func (b Board) IsFilled() bool {
+	for i := range b {
+		if slices.Contains(b[i], CellUndetermined) {
+			return false
+		}
+	}
+	return true
+}
+
+func (b Board) at(row, col int) Cell {
+	return b[row][col]
+}
+
+func (b Board) set(row, col int, c Cell) {
+	b[row][col] = c
+}
+
+func (b Board) line(ref LineRef, dst []Cell) []Cell {
+	switch ref.Kind {
+	case LineKindRow:
+		return append(dst[:0], b[ref.Index]...)
+	case LineKindColumn:
+		dst = slices.Grow(dst[:0], len(b))
+		for i := range b {
+			dst = append(dst, b[i][ref.Index])
+		}
+		return dst
+	default:
+		panic("invalid linekind accessor")
+	}
+}
+
+func (b Board) setLine(ref LineRef, cells []Cell) {
+	switch ref.Kind {
+	case LineKindRow:
+		copy(b[ref.Index], cells)
+	case LineKindColumn:
+		for i := range cells {
+			b[i][ref.Index] = cells[i]
+		}
+	default:
+		panic("invalid linekind accessor")
+	}
+}
+
+func (b Board) cloneGrid() grid {
+	return b.clone()
+}
+
+// 1本の行・列の黒ブロックの長さ。黒が無ければ [0]
+func lineHints(cells []Cell) []int {
+	var hints []int
+	run := 0
+	for _, c := range cells {
+		if c == CellBlack {
+			run++
+			continue
+		}
+		if run > 0 {
+			hints = append(hints, run)
+			run = 0
+		}
+	}
+	if run > 0 {
+		hints = append(hints, run)
+	}
+	if len(hints) == 0 {
+		return []int{0}
+	}
+	return hints
+}
+
+// 盤面の黒ブロックから行と列のヒントを作る
+func (b Board) Hints() (rowHints, colHints [][]int) {
+	rowHints = make([][]int, b.GetRows())
+	for i := range rowHints {
+		rowHints[i] = lineHints(b.line(LineRef{LineKindRow, i}, nil))
+	}
+	colHints = make([][]int, b.GetColumns())
+	for j := range colHints {
+		colHints[j] = lineHints(b.line(LineRef{LineKindColumn, j}, nil))
+	}
+	return rowHints, colHints
+}
+
 func (b Board) GetRows() int {
 	return len(b)
 }
@@ -44,6 +149,8 @@ func (b Board) GetColumns() int {
 	return len(b[0])
 }
 
+const colorSymbols = "123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
 func (b Board) Print() []string {
 	var ss []string
 	for i := range b {
@@ -56,6 +163,9 @@ func (b Board) Print() []string {
 				s.WriteString("_")
 			case CellUndetermined:
 				s.WriteString("?")
+			default:
+				// 黒以外の色は 1-9a-z... で表す
+				s.WriteByte(colorSymbols[b[i][j].Color()-1])
 			}
 		}
 		ss = append(ss, s.String())
@@ -64,23 +174,232 @@ func (b Board) Print() []string {
 }
 
 type Game struct {
-	board    Board
+	board    grid
 	rowHints [][]int
 	colHints [][]int
+	// 多色のときだけ持つ。nil なら全て黒
+	rowColors [][]Color
+	colColors [][]Color
+	// これまでに進めたパスの数。Observer に渡すパスの番号にする。複製には引き継がない
+	passes int
+}
+
+// ヒントの最小配置の長さ。同じ色が続くブロックの間だけ白が要る。ヒントは検証済みであること
+func minPlacementLength(hints []int, colors []Color) int {
+	hints = blockHints(hints)
+	length := 0
+	for j, h := range hints {
+		length += h
+		if j > 0 && (colors == nil || colors[j-1] == colors[j]) {
+			length++
+		}
+	}
+	return length
+}
+
+func validateLineHints(ref LineRef, hints []int, colors []Color, length int) error {
+	if len(hints) == 0 {
+		return fmt.Errorf("%s: %w", ref, ErrEmptyLineHints)
+	}
+	for _, h := range hints {
+		if h < 0 {
+			return fmt.Errorf("%s %v: %w", ref, hints, ErrNegativeHint)
+		}
+		if h == 0 && len(hints) != 1 {
+			return fmt.Errorf("%s %v: %w", ref, hints, ErrInvalidZeroHint)
+		}
+	}
+	for _, c := range colors {
+		if int(c) >= MaxColors {
+			return fmt.Errorf("%s %v: %w (%d)", ref, colors, ErrTooManyColors, c)
+		}
+	}
+	if minPlacementLength(hints, colors) > length {
+		return fmt.Errorf("%s %v: %w (長さ%d)", ref, hints, ErrHintsTooLong, length)
+	}
+	return nil
+}
+
+// 色ごとのヒントの合計
+func sumHints(hintsList [][]int, colorsList [][]Color) map[Color]int {
+	sums := map[Color]int{}
+	for i, hints := range hintsList {
+		for j, h := range hints {
+			if h == 0 {
+				continue
+			}
+			color := ColorBlack
+			if colorsList != nil {
+				color = colorsList[i][j]
+			}
+			sums[color] += h
+		}
+	}
+	return sums
 }
 
 func NewGame(rowHints, colHints [][]int) (*Game, error) {
+	return newGame(rowHints, colHints, nil, nil)
+}
+
+// 色付きのヒントで多色のパズルを作る。色が黒だけなら NewGame と同じ
+func NewColorGame(rowHints, colHints [][]ColorHint) (*Game, error) {
+	rows, rowColors := splitColorHints(rowHints)
+	cols, colColors := splitColorHints(colHints)
+	return newGame(rows, cols, rowColors, colColors)
+}
+
+func newGame(rowHints, colHints [][]int, rowColors, colColors [][]Color) (*Game, error) {
 	if len(rowHints) == 0 || len(colHints) == 0 {
-		return nil, errors.New("rowHints,colHintsは1より大きい必要がある")
+		return nil, ErrNoHints
 	}
-	// TODO: hintsの最小配置がlen(cell)より小さい必要がある
 	width := len(colHints)
 	height := len(rowHints)
+	g := &Game{board: newBoard(height, width), rowHints: rowHints, colHints: colHints, rowColors: rowColors, colColors: colColors}
+
+	for i, hints := range rowHints {
+		ref := LineRef{LineKindRow, i}
+		if err := validateLineHints(ref, hints, g.colorsOf(ref), width); err != nil {
+			return nil, err
+		}
+	}
+	for i, hints := range colHints {
+		ref := LineRef{LineKindColumn, i}
+		if err := validateLineHints(ref, hints, g.colorsOf(ref), height); err != nil {
+			return nil, err
+		}
+	}
+	rows, cols := sumHints(rowHints, rowColors), sumHints(colHints, colColors)
+	for color := range Color(MaxColors) {
+		if rows[color] == cols[color] {
+			continue
+		}
+		if color == ColorBlack {
+			return nil, fmt.Errorf("%w (Row=%d, Col=%d)", ErrBlackCountMismatch, rows[color], cols[color])
+		}
+		return nil, fmt.Errorf("%w (色%d: Row=%d, Col=%d)", ErrColorCountMismatch, color, rows[color], cols[color])
+	}
+	return g, nil
+}
+
+func (g *Game) hintsOf(ref LineRef) []int {
+	if ref.Kind == LineKindRow {
+		return g.rowHints[ref.Index]
+	}
+	return g.colHints[ref.Index]
+}
+
+// 黒だけのパズルなら nil
+func (g *Game) colorsOf(ref LineRef) []Color {
+	if ref.Kind == LineKindRow {
+		if g.rowColors == nil {
+			return nil
+		}
+		return g.rowColors[ref.Index]
+	}
+	if g.colColors == nil {
+		return nil
+	}
+	return g.colColors[ref.Index]
+}
+
+func (g *Game) IsMonochrome() bool {
+	for _, colors := range g.rowColors {
+		if !isMonochrome(colors) {
+			return false
+		}
+	}
+	for _, colors := range g.colColors {
+		if !isMonochrome(colors) {
+			return false
+		}
+	}
+	return true
+}
+
+// ブロックの色の集合
+func hintColorSet(hints []int, colors []Color) cellSet {
+	var set cellSet
+	for j := range blockHints(hints) {
+		color := ColorBlack
+		if colors != nil {
+			color = colors[j]
+		}
+		set |= cellBit(ColorCell(color))
+	}
+	return set
+}
+
+// セルに置ける値の候補。行と列の両方に現れる色を番号順に並べ、最後に白を置く
+func (g *Game) cellCandidates(row, col int) []Cell {
+	rowRef, colRef := LineRef{LineKindRow, row}, LineRef{LineKindColumn, col}
+	set := hintColorSet(g.hintsOf(rowRef), g.colorsOf(rowRef)) & hintColorSet(g.hintsOf(colRef), g.colorsOf(colRef))
 
-	b := newBoard(height, width)
-	return &Game{b, rowHints, colHints}, nil
+	var candidates []Cell
+	for color := range Color(MaxColors) {
+		if set.has(ColorCell(color)) {
+			candidates = append(candidates, ColorCell(color))
+		}
+	}
+	return append(candidates, CellWhite)
+}
+
+// 盤面だけを複製する。ヒントは共有する
+func (g *Game) clone() *Game {
+	return &Game{board: g.board.cloneGrid(), rowHints: g.rowHints, colHints: g.colHints, rowColors: g.rowColors, colColors: g.colColors}
+}
+
+// 盤面を bit 列で持つ形式に切り替える。大きな白黒のパズル向けで、多色には使えない
+func (g *Game) UseBitBoard() error {
+	if !g.IsMonochrome() {
+		return ErrUnsupportedColor
+	}
+	bits := newBitBoard(g.board.GetRows(), g.board.GetColumns())
+	for i := range g.board.GetRows() {
+		ref := LineRef{LineKindRow, i}
+		bits.setLine(ref, g.board.line(ref, nil))
+	}
+	g.board = bits
+	return nil
+}
+
+// 行のヒントの複製
+func (g Game) RowHints() [][]int {
+	return cloneHints(g.rowHints)
+}
+
+// 列のヒントの複製
+func (g Game) ColHints() [][]int {
+	return cloneHints(g.colHints)
+}
+
+// 行・列のブロックの色の複製。黒だけのパズルなら nil
+func (g Game) LineColors(ref LineRef) []Color {
+	return slices.Clone(g.colorsOf(ref))
+}
+
+func cloneHints(hintsList [][]int) [][]int {
+	cloned := make([][]int, len(hintsList))
+	for i, hints := range hintsList {
+		cloned[i] = slices.Clone(hints)
+	}
+	return cloned
+}
+
+// 現在の盤面の複製
+func (g Game) Board() Board {
+	return toBoard(g.board)
+}
+
+func (g Game) IsSolved() bool {
+	for i := range g.board.GetRows() {
+		if slices.Contains(g.board.line(LineRef{LineKindRow, i}, nil), CellUndetermined) {
+			return false
+		}
+	}
+	return true
 }
 
 func (g Game) PrintBoard() []string {
-	return g.board.Print()
+	return g.Board().Print()
 }
@@ -0,0 +1,97 @@
+package picrosssolver_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	picrosssolver "github.com/inahym196/picross-solver"
+)
+
+func TestNewGameValidation(t *testing.T) {
+	tests := []struct {
+		rowHints [][]int
+		colHints [][]int
+		expected error
+		line     string
+	}{
+		{nil, ParseHints("1"), picrosssolver.ErrNoHints, ""},
+		{[][]int{{1}, {}}, ParseHints("1 0"), picrosssolver.ErrEmptyLineHints, "Row[1]"},
+		{ParseHints("1 1"), [][]int{{2}, {-1}}, picrosssolver.ErrNegativeHint, "Col[1]"},
+		{ParseHints("1-0 1"), ParseHints("2 0"), picrosssolver.ErrInvalidZeroHint, "Row[0]"},
+		{ParseHints("0 0 0 1-2"), ParseHints("1 0 1"), picrosssolver.ErrHintsTooLong, "Row[3]"},
+		{ParseHints("1 1"), ParseHints("0 0 0 0 0 0 0 3"), picrosssolver.ErrHintsTooLong, "Col[7]"},
+		{ParseHints("2 1"), ParseHints("1 1"), picrosssolver.ErrBlackCountMismatch, ""},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("case%d", i), func(t *testing.T) {
+			game, err := picrosssolver.NewGame(tt.rowHints, tt.colHints)
+
+			if game != nil {
+				t.Errorf("expected nil game, got %v", game.PrintBoard())
+			}
+			if !errors.Is(err, tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, err)
+			}
+			if !strings.Contains(err.Error(), tt.line) {
+				t.Errorf("expected error to name %s, got %v", tt.line, err)
+			}
+		})
+	}
+}
+
+func TestNewGameValid(t *testing.T) {
+	if _, err := picrosssolver.NewGame(ParseHints("1-1-1 0 5"), ParseHints("2 1 2 1 2")); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestNewColorGameValidation(t *testing.T) {
+	b := func(n int) picrosssolver.ColorHint {
+		return picrosssolver.ColorHint{Length: n, Color: picrosssolver.ColorBlack}
+	}
+	r := func(n int) picrosssolver.ColorHint { return picrosssolver.ColorHint{Length: n, Color: 1} }
+
+	tests := []struct {
+		rowHints [][]picrosssolver.ColorHint
+		colHints [][]picrosssolver.ColorHint
+		expected error
+	}{
+		{[][]picrosssolver.ColorHint{{b(1), r(1)}}, [][]picrosssolver.ColorHint{{b(1)}, {r(1)}}, nil},
+		{[][]picrosssolver.ColorHint{{b(1), b(1)}}, [][]picrosssolver.ColorHint{{b(1)}, {b(1)}}, picrosssolver.ErrHintsTooLong},
+		{[][]picrosssolver.ColorHint{{b(1), r(1)}}, [][]picrosssolver.ColorHint{{b(1)}, {b(1)}}, picrosssolver.ErrBlackCountMismatch},
+		{[][]picrosssolver.ColorHint{{b(1), r(1)}, {r(1)}}, [][]picrosssolver.ColorHint{{b(1)}, {r(1)}}, picrosssolver.ErrColorCountMismatch},
+		{[][]picrosssolver.ColorHint{{{Length: 1, Color: 200}}}, [][]picrosssolver.ColorHint{{{Length: 1, Color: 200}}}, picrosssolver.ErrTooManyColors},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("case%d", i), func(t *testing.T) {
+			_, err := picrosssolver.NewColorGame(tt.rowHints, tt.colHints)
+
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestBoardHints(t *testing.T) {
+	B, W := picrosssolver.CellBlack, picrosssolver.CellWhite
+	board := picrosssolver.Board{
+		{B, W, B},
+		{W, W, W},
+		{B, B, W},
+	}
+
+	rowHints, colHints := board.Hints()
+
+	if expected := ParseHints("1-1 0 2"); !reflect.DeepEqual(rowHints, expected) {
+		t.Errorf("expected %v, got %v", expected, rowHints)
+	}
+	if expected := ParseHints("1-1 1 1"); !reflect.DeepEqual(colHints, expected) {
+		t.Errorf("expected %v, got %v", expected, colHints)
+	}
+}
@@ -0,0 +1,143 @@
+// generator は解が一意なパズルをランダムに作る
+package generator
+
+import (
+	"errors"
+	"math/rand/v2"
+	"slices"
+
+	picrosssolver "github.com/inahym196/picross-solver"
+)
+
+var ErrGiveUp = errors.New("条件を満たすパズルを作れなかった")
+
+const defaultMaxAttempts = 100
+
+type Options struct {
+	Width, Height int
+	// 黒セルの割合。0 より大きく 1 より小さい
+	Density float64
+	Seed    uint64
+	// 盤面を作り直す上限。0 なら 100
+	MaxAttempts int
+	// nil でなければこの難易度のパズルだけを返す
+	Difficulty *picrosssolver.Difficulty
+	// nil なら picrosssolver.NewSolver() を使う
+	Solver *picrosssolver.Solver
+}
+
+type Puzzle struct {
+	Solution picrosssolver.Board
+	RowHints [][]int
+	ColHints [][]int
+	Rating   picrosssolver.Rating
+	// 解くのに使ったルール名。名前順
+	Rules []string
+	// 盤面を作り直した回数を含む試行回数
+	Attempts int
+}
+
+func randomBoard(r *rand.Rand, opts Options) picrosssolver.Board {
+	board := make(picrosssolver.Board, opts.Height)
+	for i := range board {
+		board[i] = make([]picrosssolver.Cell, opts.Width)
+		for j := range board[i] {
+			if r.Float64() < opts.Density {
+				board[i][j] = picrosssolver.CellBlack
+			} else {
+				board[i][j] = picrosssolver.CellWhite
+			}
+		}
+	}
+	return board
+}
+
+// 2つの解で値が異なるセルを乱数で選ぶ
+func ambiguousCell(r *rand.Rand, a, b picrosssolver.Board) (int, int) {
+	var cells [][2]int
+	for i := range a {
+		for j := range a[i] {
+			if a[i][j] != b[i][j] {
+				cells = append(cells, [2]int{i, j})
+			}
+		}
+	}
+	cell := cells[r.IntN(len(cells))]
+	return cell[0], cell[1]
+}
+
+func flip(c picrosssolver.Cell) picrosssolver.Cell {
+	if c == picrosssolver.CellBlack {
+		return picrosssolver.CellWhite
+	}
+	return picrosssolver.CellBlack
+}
+
+// Seed が同じなら同じパズルを返す。解が複数ある盤面は、解の間で異なるセルを反転して
+// 一意になるまで調整し、調整しきれなければ盤面を作り直す
+func Generate(opts Options) (*Puzzle, error) {
+	if opts.Width <= 0 || opts.Height <= 0 {
+		return nil, errors.New("Width, Height は 1 以上である必要がある")
+	}
+	if opts.Density <= 0 || opts.Density >= 1 {
+		return nil, errors.New("Density は 0 より大きく 1 より小さい必要がある")
+	}
+	maxAttempts := opts.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+	solver := picrosssolver.NewSolver()
+	if opts.Solver != nil {
+		solver = *opts.Solver
+	}
+	r := rand.New(rand.NewPCG(opts.Seed, opts.Seed))
+
+	attempts := 0
+	for range maxAttempts {
+		board := randomBoard(r, opts)
+		for range opts.Width * opts.Height {
+			attempts++
+			rowHints, colHints := board.Hints()
+			game, err := picrosssolver.NewGame(rowHints, colHints)
+			if err != nil {
+				return nil, err
+			}
+			uniqueness, boards := solver.CheckUniqueness(game)
+			if uniqueness != picrosssolver.UniquenessMultiple {
+				puzzle, ok := newPuzzle(solver, game, board, attempts, opts)
+				if ok {
+					return puzzle, nil
+				}
+				break
+			}
+			i, j := ambiguousCell(r, boards[0], boards[1])
+			board[i][j] = flip(board[i][j])
+		}
+	}
+	return nil, ErrGiveUp
+}
+
+func newPuzzle(solver picrosssolver.Solver, game *picrosssolver.Game, board picrosssolver.Board, attempts int, opts Options) (*Puzzle, bool) {
+	rating, err := solver.Rate(game)
+	if err != nil {
+		return nil, false
+	}
+	if opts.Difficulty != nil && rating.Difficulty != *opts.Difficulty {
+		return nil, false
+	}
+	rules := make([]string, 0, len(rating.Rules))
+	for name := range rating.Rules {
+		rules = append(rules, name)
+	}
+	slices.Sort(rules)
+
+	rowHints, colHints := board.Hints()
+	return &Puzzle{
+		Solution: board,
+		RowHints: rowHints,
+		ColHints: colHints,
+		Rating:   rating,
+		Rules:    rules,
+		Attempts: attempts,
+	}, true
+}
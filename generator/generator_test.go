@@ -0,0 +1,79 @@
+package generator_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	picrosssolver "github.com/inahym196/picross-solver"
+	"github.com/inahym196/picross-solver/generator"
+)
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		width, height int
+		density       float64
+		seed          uint64
+	}{
+		{5, 5, 0.5, 1},
+		{8, 6, 0.6, 2},
+		{10, 10, 0.55, 3},
+	}
+	solver := picrosssolver.NewSolver()
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("case%d", i), func(t *testing.T) {
+			opts := generator.Options{Width: tt.width, Height: tt.height, Density: tt.density, Seed: tt.seed}
+
+			puzzle, err := generator.Generate(opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			game, err := picrosssolver.NewGame(puzzle.RowHints, puzzle.ColHints)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			uniqueness, boards := solver.CheckUniqueness(game)
+			if uniqueness != picrosssolver.UniquenessUnique {
+				t.Fatalf("expected unique, got %s", uniqueness)
+			}
+			if !reflect.DeepEqual(boards[0], puzzle.Solution) {
+				t.Errorf("expected %v, got %v", puzzle.Solution.Print(), boards[0].Print())
+			}
+			if len(puzzle.Rules) == 0 {
+				t.Errorf("expected rules to be reported")
+			}
+
+			again, _ := generator.Generate(opts)
+			if !reflect.DeepEqual(puzzle, again) {
+				t.Errorf("expected same puzzle for same seed")
+			}
+		})
+	}
+}
+
+func TestGenerateDifficulty(t *testing.T) {
+	easy := picrosssolver.DifficultyEasy
+	opts := generator.Options{Width: 5, Height: 5, Density: 0.6, Seed: 7, Difficulty: &easy}
+
+	puzzle, err := generator.Generate(opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if puzzle.Rating.Difficulty != easy {
+		t.Errorf("expected %s, got %s", easy, puzzle.Rating.Difficulty)
+	}
+}
+
+func TestGenerateInvalidOptions(t *testing.T) {
+	if _, err := generator.Generate(generator.Options{Width: 5, Height: 5, Density: 1}); err == nil {
+		t.Errorf("expected error for density 1")
+	}
+	expert := picrosssolver.DifficultyExpert
+	_, err := generator.Generate(generator.Options{Width: 2, Height: 2, Density: 0.5, MaxAttempts: 3, Difficulty: &expert})
+	if !errors.Is(err, generator.ErrGiveUp) {
+		t.Errorf("expected ErrGiveUp, got %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+// imageconv は画像を白黒のパズルに変換する
+package imageconv
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+
+	picrosssolver "github.com/inahym196/picross-solver"
+)
+
+type Options struct {
+	Width, Height int
+	// この明るさ (0-1) 未満を黒にする。0 なら 0.5
+	Threshold float64
+	// Floyd–Steinberg 法で誤差拡散する
+	Dither bool
+	// nil なら picrosssolver.NewSolver() を使う
+	Solver *picrosssolver.Solver
+}
+
+type Result struct {
+	Board    picrosssolver.Board
+	RowHints [][]int
+	ColHints [][]int
+	// 行・列のルールだけで全てのセルが確定したか。仮定や探索が要るなら false
+	LineSolvable bool
+	Unique       bool
+	// 行・列のルールだけでは確定しなかったセル
+	Undetermined []image.Point
+	// 解が複数あるとき、2つの解で値が異なるセル
+	Ambiguous []image.Point
+}
+
+// PNG, GIF, JPEG を読み込んで変換する
+func Decode(r io.Reader, opts Options) (*Result, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	return Convert(img, opts)
+}
+
+// 白を背景として合成した明るさ (0-1)
+func luminance(c color.Color) float64 {
+	r, g, b, a := c.RGBA()
+	gray := (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / 0xffff
+	alpha := float64(a) / 0xffff
+	return gray + (1 - alpha)
+}
+
+// 画像を width x height に区切り、区画ごとの明るさの平均を取る
+func sample(img image.Image, width, height int) [][]float64 {
+	bounds := img.Bounds()
+	levels := make([][]float64, height)
+	for i := range levels {
+		levels[i] = make([]float64, width)
+		y0 := bounds.Min.Y + i*bounds.Dy()/height
+		y1 := max(bounds.Min.Y+(i+1)*bounds.Dy()/height, y0+1)
+		for j := range levels[i] {
+			x0 := bounds.Min.X + j*bounds.Dx()/width
+			x1 := max(bounds.Min.X+(j+1)*bounds.Dx()/width, x0+1)
+			sum := 0.0
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					sum += luminance(img.At(x, y))
+				}
+			}
+			levels[i][j] = sum / float64((y1-y0)*(x1-x0))
+		}
+	}
+	return levels
+}
+
+func quantize(levels [][]float64, threshold float64, dither bool) picrosssolver.Board {
+	board := make(picrosssolver.Board, len(levels))
+	for i := range levels {
+		board[i] = make([]picrosssolver.Cell, len(levels[i]))
+		for j, level := range levels[i] {
+			value := 1.0
+			board[i][j] = picrosssolver.CellWhite
+			if level < threshold {
+				value = 0
+				board[i][j] = picrosssolver.CellBlack
+			}
+			if !dither {
+				continue
+			}
+			spread := func(y, x int, weight float64) {
+				if y < len(levels) && x >= 0 && x < len(levels[y]) {
+					levels[y][x] += (level - value) * weight
+				}
+			}
+			spread(i, j+1, 7.0/16)
+			spread(i+1, j-1, 3.0/16)
+			spread(i+1, j, 5.0/16)
+			spread(i+1, j+1, 1.0/16)
+		}
+	}
+	return board
+}
+
+func Convert(img image.Image, opts Options) (*Result, error) {
+	if opts.Width <= 0 || opts.Height <= 0 {
+		return nil, errors.New("Width, Height は 1 以上である必要がある")
+	}
+	if img.Bounds().Empty() {
+		return nil, errors.New("画像が空")
+	}
+	threshold := opts.Threshold
+	if threshold == 0 {
+		threshold = 0.5
+	}
+	solver := picrosssolver.NewSolver()
+	if opts.Solver != nil {
+		solver = *opts.Solver
+	}
+
+	board := quantize(sample(img, opts.Width, opts.Height), threshold, opts.Dither)
+	rowHints, colHints := board.Hints()
+	result := &Result{Board: board, RowHints: rowHints, ColHints: colHints}
+
+	game, err := picrosssolver.NewGame(rowHints, colHints)
+	if err != nil {
+		return nil, err
+	}
+	uniqueness, boards := solver.CheckUniqueness(game)
+	result.Unique = uniqueness == picrosssolver.UniquenessUnique
+	if uniqueness == picrosssolver.UniquenessMultiple {
+		for i := range boards[0] {
+			for j := range boards[0][i] {
+				if boards[0][i][j] != boards[1][i][j] {
+					result.Ambiguous = append(result.Ambiguous, image.Pt(j, i))
+				}
+			}
+		}
+	}
+
+	if _, _, err := solver.ApplyManyLines(game); err != nil {
+		return nil, err
+	}
+	applied := game.Board()
+	for i := range applied {
+		for j, c := range applied[i] {
+			if c == picrosssolver.CellUndetermined {
+				result.Undetermined = append(result.Undetermined, image.Pt(j, i))
+			}
+		}
+	}
+	result.LineSolvable = len(result.Undetermined) == 0
+	return result, nil
+}
@@ -0,0 +1,125 @@
+package imageconv_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"slices"
+	"testing"
+
+	picrosssolver "github.com/inahym196/picross-solver"
+	"github.com/inahym196/picross-solver/imageconv"
+)
+
+// cells の '#' を黒、それ以外を白として、1セルを scale 画素の正方形で描く
+func drawCells(cells []string, scale int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, len(cells[0])*scale, len(cells)*scale))
+	for y := range img.Bounds().Dy() {
+		for x := range img.Bounds().Dx() {
+			if cells[y/scale][x/scale] == '#' {
+				img.SetGray(x, y, color.Gray{0})
+			} else {
+				img.SetGray(x, y, color.Gray{255})
+			}
+		}
+	}
+	return img
+}
+
+func TestDecode(t *testing.T) {
+	cells := []string{
+		"_#_",
+		"###",
+		"_#_",
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, drawCells(cells, 4)); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := imageconv.Decode(&buf, imageconv.Options{Width: 3, Height: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !slices.Equal(result.Board.Print(), cells) {
+		t.Errorf("expected %v, got %v", cells, result.Board.Print())
+	}
+	if !result.LineSolvable || !result.Unique {
+		t.Errorf("expected line-solvable unique puzzle, got %+v", result)
+	}
+	if len(result.Ambiguous) != 0 || len(result.Undetermined) != 0 {
+		t.Errorf("expected no ambiguous cells, got %v %v", result.Ambiguous, result.Undetermined)
+	}
+}
+
+func TestConvertAmbiguous(t *testing.T) {
+	img := drawCells([]string{"#_", "_#"}, 3)
+
+	result, err := imageconv.Convert(img, imageconv.Options{Width: 2, Height: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Unique || result.LineSolvable {
+		t.Errorf("expected ambiguous puzzle, got %+v", result)
+	}
+	expected := []image.Point{{0, 0}, {1, 0}, {0, 1}, {1, 1}}
+	if !slices.Equal(result.Ambiguous, expected) {
+		t.Errorf("expected %v, got %v", expected, result.Ambiguous)
+	}
+	if !slices.Equal(result.Undetermined, expected) {
+		t.Errorf("expected %v, got %v", expected, result.Undetermined)
+	}
+}
+
+func TestConvertNeedsHypothesis(t *testing.T) {
+	// 一意に解けるが、行・列のルールだけでは途中で止まる
+	cells := []string{
+		"#____",
+		"__##_",
+		"_##_#",
+		"##_#_",
+		"_#_#_",
+	}
+	solver := picrosssolver.NewSolver(picrosssolver.WithHypothesis())
+
+	result, err := imageconv.Convert(drawCells(cells, 2), imageconv.Options{Width: 5, Height: 5, Solver: &solver})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !result.Unique || result.LineSolvable {
+		t.Errorf("expected unique but not line-solvable puzzle, got %+v", result)
+	}
+	if len(result.Undetermined) == 0 {
+		t.Errorf("expected undetermined cells")
+	}
+}
+
+func TestConvertDither(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 8, 8))
+	for i := range img.Pix {
+		img.Pix[i] = 128
+	}
+
+	flat, _ := imageconv.Convert(img, imageconv.Options{Width: 8, Height: 8, Threshold: 0.4})
+	dithered, _ := imageconv.Convert(img, imageconv.Options{Width: 8, Height: 8, Threshold: 0.4, Dither: true})
+
+	count := func(r *imageconv.Result) int {
+		n := 0
+		for _, hints := range r.RowHints {
+			for _, h := range hints {
+				n += h
+			}
+		}
+		return n
+	}
+	if count(flat) != 0 {
+		t.Errorf("expected no black cells without dithering, got %d", count(flat))
+	}
+	if n := count(dithered); n < 24 || n > 40 {
+		t.Errorf("expected about half black cells with dithering, got %d", n)
+	}
+}
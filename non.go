@@ -0,0 +1,185 @@
+package picrosssolver
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// 読み込み時のエラー。Line は1始まりの行番号
+type ParseError struct {
+	Line int
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%d行目: %s", e.Line, e.Msg)
+}
+
+func parseNonHints(s string) ([]int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return []int{0}, nil
+	}
+	parts := strings.Split(s, ",")
+	hints := make([]int, 0, len(parts))
+	for _, p := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil {
+			return nil, fmt.Errorf("ヒントが数値ではない: %q", p)
+		}
+		hints = append(hints, n)
+	}
+	return hints, nil
+}
+
+func parseNonGoal(s string, height, width int) (Board, error) {
+	s = strings.Trim(strings.TrimSpace(s), `"`)
+	if len(s) != height*width {
+		return nil, fmt.Errorf("goal の長さが %d ではない: %d", height*width, len(s))
+	}
+	goal := newBoard(height, width)
+	for i, r := range s {
+		switch r {
+		case '0':
+			goal[i/width][i%width] = CellWhite
+		case '1':
+			goal[i/width][i%width] = CellBlack
+		default:
+			return nil, fmt.Errorf("goal に不正な文字がある: %q", r)
+		}
+	}
+	return goal, nil
+}
+
+// Steve Simpson の .non 形式を読み込む。goal が無ければ nil を返す
+func ReadNon(r io.Reader) (*Game, Board, error) {
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	next := func() (string, bool) {
+		if !scanner.Scan() {
+			return "", false
+		}
+		lineNo++
+		return strings.TrimRight(scanner.Text(), "\r"), true
+	}
+	fail := func(format string, args ...any) error {
+		return &ParseError{lineNo, fmt.Sprintf(format, args...)}
+	}
+
+	var width, height int
+	var rowHints, colHints [][]int
+	var goalLine int
+	var goalText string
+
+	readSection := func(count int) ([][]int, error) {
+		if count == 0 {
+			return nil, fail("width, height より前にヒントがある")
+		}
+		section := make([][]int, 0, count)
+		for len(section) < count {
+			line, ok := next()
+			if !ok {
+				return nil, fail("ヒントが %d 行に足りない", count)
+			}
+			hints, err := parseNonHints(line)
+			if err != nil {
+				return nil, fail("%v", err)
+			}
+			section = append(section, hints)
+		}
+		return section, nil
+	}
+
+	for {
+		line, ok := next()
+		if !ok {
+			break
+		}
+		keyword, value, _ := strings.Cut(strings.TrimSpace(line), " ")
+		value = strings.TrimSpace(value)
+
+		var err error
+		switch keyword {
+		case "width":
+			if width, err = strconv.Atoi(value); err != nil || width <= 0 {
+				return nil, nil, fail("width が不正: %q", value)
+			}
+		case "height":
+			if height, err = strconv.Atoi(value); err != nil || height <= 0 {
+				return nil, nil, fail("height が不正: %q", value)
+			}
+		case "rows":
+			if rowHints, err = readSection(height); err != nil {
+				return nil, nil, err
+			}
+		case "columns":
+			if colHints, err = readSection(width); err != nil {
+				return nil, nil, err
+			}
+		case "goal":
+			goalLine, goalText = lineNo, value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	if rowHints == nil || colHints == nil {
+		return nil, nil, fail("rows, columns のどちらかが無い")
+	}
+
+	game, err := NewGame(rowHints, colHints)
+	if err != nil {
+		return nil, nil, err
+	}
+	if goalLine == 0 {
+		return game, nil, nil
+	}
+	goal, err := parseNonGoal(goalText, height, width)
+	if err != nil {
+		return nil, nil, &ParseError{goalLine, err.Error()}
+	}
+	return game, goal, nil
+}
+
+func formatNonHints(hints []int) string {
+	parts := make([]string, len(hints))
+	for i, h := range hints {
+		parts[i] = strconv.Itoa(h)
+	}
+	return strings.Join(parts, ",")
+}
+
+// .non 形式で書き出す。goal が nil なら goal 行は出力しない
+func WriteNon(w io.Writer, game *Game, goal Board) error {
+	if !game.IsMonochrome() {
+		return ErrUnsupportedColor
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "width %d\nheight %d\n\n", len(game.colHints), len(game.rowHints))
+	b.WriteString("rows\n")
+	for _, hints := range game.rowHints {
+		b.WriteString(formatNonHints(hints) + "\n")
+	}
+	b.WriteString("\ncolumns\n")
+	for _, hints := range game.colHints {
+		b.WriteString(formatNonHints(hints) + "\n")
+	}
+	if goal != nil {
+		b.WriteString("\ngoal \"")
+		for i := range goal {
+			for _, c := range goal[i] {
+				if c == CellBlack {
+					b.WriteByte('1')
+				} else {
+					b.WriteByte('0')
+				}
+			}
+		}
+		b.WriteString("\"\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
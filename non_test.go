@@ -0,0 +1,100 @@
+package picrosssolver_test
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	picrosssolver "github.com/inahym196/picross-solver"
+)
+
+const sampleNon = `catalogue "sample"
+title "cross"
+width 3
+height 3
+
+rows
+1
+3
+1
+
+columns
+1
+3
+1
+
+goal "010111010"
+`
+
+func TestReadNon(t *testing.T) {
+	game, goal, err := picrosssolver.ReadNon(strings.NewReader(sampleNon))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"_#_", "###", "_#_"}
+	if !slices.Equal(goal.Print(), expected) {
+		t.Errorf("expected goal %v, got %v", expected, goal.Print())
+	}
+	if _, _, err := picrosssolver.NewSolver().ApplyMany(game); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(game.PrintBoard(), expected) {
+		t.Errorf("expected %v, got %v", expected, game.PrintBoard())
+	}
+}
+
+func TestNonRoundTrip(t *testing.T) {
+	game, goal, err := picrosssolver.ReadNon(strings.NewReader(sampleNon))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var b strings.Builder
+	if err := picrosssolver.WriteNon(&b, game, goal); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	game2, goal2, err := picrosssolver.ReadNon(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, b.String())
+	}
+
+	if !slices.Equal(goal.Print(), goal2.Print()) {
+		t.Errorf("expected goal %v, got %v", goal.Print(), goal2.Print())
+	}
+	var b2 strings.Builder
+	picrosssolver.WriteNon(&b2, game2, goal2)
+	if b.String() != b2.String() {
+		t.Errorf("expected\n%s\ngot\n%s", b.String(), b2.String())
+	}
+}
+
+func TestReadNonError(t *testing.T) {
+	tests := []struct {
+		input string
+		line  int
+	}{
+		{"width 2\nheight x\n", 2},
+		{"rows\n1\n", 1},
+		{"width 2\nheight 2\nrows\n1\n1,a\n", 5},
+		{"width 2\nheight 2\nrows\n1\n", 4},
+		{"width 2\nheight 2\nrows\n1\n1\ncolumns\n1\n1\ngoal \"1001x\"\n", 9},
+		{"width 2\nheight 2\nrows\n1\n1\ncolumns\n1\n1\ngoal \"1021\"\n", 9},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("case%d", i), func(t *testing.T) {
+			_, _, err := picrosssolver.ReadNon(strings.NewReader(tt.input))
+
+			var pe *picrosssolver.ParseError
+			if !errors.As(err, &pe) {
+				t.Fatalf("expected *ParseError, got %v", err)
+			}
+			if pe.Line != tt.line {
+				t.Errorf("expected line %d, got %d (%v)", tt.line, pe.Line, err)
+			}
+		})
+	}
+}
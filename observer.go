@@ -0,0 +1,97 @@
+package picrosssolver
+
+// Solver の進行を受け取る。メトリクスの収集やアニメーションに使う。
+// 渡されたスライスは呼び出しの間だけ有効で、変更してはいけない
+type Observer interface {
+	// パス n の開始
+	OnPassStart(n int)
+	// 行・列のルールを適用する直前のヒントとセル
+	OnLineEvaluated(ref LineRef, hints []int, cells []Cell)
+	// 盤面に反映された推論
+	OnDeduction(ded Deduction)
+	// パス n の終了。changed はそのパスで盤面が変わったか
+	OnPassEnd(n int, changed bool)
+}
+
+// 何もしない Observer。埋め込んで必要なメソッドだけ実装する
+type NopObserver struct{}
+
+func (NopObserver) OnPassStart(int)                        {}
+func (NopObserver) OnLineEvaluated(LineRef, []int, []Cell) {}
+func (NopObserver) OnDeduction(Deduction)                  {}
+func (NopObserver) OnPassEnd(int, bool)                    {}
+
+type multiObserver []Observer
+
+// observers を順に呼ぶ Observer にまとめる。nil は除く
+func Observers(observers ...Observer) Observer {
+	var m multiObserver
+	for _, o := range observers {
+		switch o := o.(type) {
+		case nil:
+		case multiObserver:
+			m = append(m, o...)
+		default:
+			m = append(m, o)
+		}
+	}
+	switch len(m) {
+	case 0:
+		return nil
+	case 1:
+		return m[0]
+	}
+	return m
+}
+
+func (m multiObserver) OnPassStart(n int) {
+	for _, o := range m {
+		o.OnPassStart(n)
+	}
+}
+
+func (m multiObserver) OnLineEvaluated(ref LineRef, hints []int, cells []Cell) {
+	for _, o := range m {
+		o.OnLineEvaluated(ref, hints, cells)
+	}
+}
+
+func (m multiObserver) OnDeduction(ded Deduction) {
+	for _, o := range m {
+		o.OnDeduction(ded)
+	}
+}
+
+func (m multiObserver) OnPassEnd(n int, changed bool) {
+	for _, o := range m {
+		o.OnPassEnd(n, changed)
+	}
+}
+
+// 進行を o に通知する。複数回指定すると指定した順にすべて呼ぶ
+func WithObserver(o Observer) Option {
+	return func(s *Solver) {
+		s.observer = Observers(s.observer, o)
+	}
+}
+
+// game の次のパスの番号を取って開始を通知する。番号は同じ game に対する
+// ApplyOnce、ApplyMany、ApplyManyQueued を通して 0 から続く
+func (s Solver) startPass(game *Game) int {
+	n := game.passes
+	game.passes++
+	s.notifyPassStart(n)
+	return n
+}
+
+func (s Solver) notifyPassStart(n int) {
+	if s.observer != nil {
+		s.observer.OnPassStart(n)
+	}
+}
+
+func (s Solver) notifyPassEnd(n int, changed bool) {
+	if s.observer != nil {
+		s.observer.OnPassEnd(n, changed)
+	}
+}
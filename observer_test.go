@@ -0,0 +1,194 @@
+package picrosssolver_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	picrosssolver "github.com/inahym196/picross-solver"
+)
+
+// 通知を文字列で記録する
+type recordingObserver struct {
+	events *[]string
+}
+
+func (o recordingObserver) OnPassStart(n int) {
+	*o.events = append(*o.events, fmt.Sprintf("start %d", n))
+}
+
+func (o recordingObserver) OnLineEvaluated(ref picrosssolver.LineRef, hints []int, cells []picrosssolver.Cell) {
+	*o.events = append(*o.events, fmt.Sprintf("line %s %v %v", ref, hints, cells))
+}
+
+func (o recordingObserver) OnDeduction(ded picrosssolver.Deduction) {
+	*o.events = append(*o.events, "ded "+ded.String())
+}
+
+func (o recordingObserver) OnPassEnd(n int, changed bool) {
+	*o.events = append(*o.events, fmt.Sprintf("end %d %v", n, changed))
+}
+
+// 推論の数だけを数える
+type countingObserver struct {
+	picrosssolver.NopObserver
+	count *int
+}
+
+func (o countingObserver) OnDeduction(picrosssolver.Deduction) { *o.count++ }
+
+func TestObserver(t *testing.T) {
+	var events []string
+	solver := picrosssolver.NewSolver(picrosssolver.WithObserver(recordingObserver{&events}))
+	game, _ := picrosssolver.NewGame(ParseHints("0 2"), ParseHints("1 1"))
+
+	if _, _, err := solver.ApplyMany(game); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"start 0",
+		"line Row[0] [0] [U U]",
+		"ded ZeroHintRule Row[0] [0] [U U] -> [W W]",
+		"line Row[1] [2] [U U]",
+		"ded MinimumSpacingRule Row[1] [2] [U U] -> [B B]",
+		"line Col[0] [1] [W B]",
+		"line Col[1] [1] [W B]",
+		"end 0 true",
+		"start 1",
+		"line Row[0] [0] [W W]",
+		"line Row[1] [2] [B B]",
+		"line Col[0] [1] [W B]",
+		"line Col[1] [1] [W B]",
+		"end 1 false",
+	}
+	if !reflect.DeepEqual(expected, events) {
+		t.Errorf("expected %v, got %v", expected, events)
+	}
+}
+
+func TestObserverHypothesis(t *testing.T) {
+	var count int
+	solver := picrosssolver.NewSolver(picrosssolver.WithHypothesis(), picrosssolver.WithObserver(countingObserver{count: &count}))
+	game, _ := picrosssolver.NewGame(ParseHints(hypothesisRowHints), ParseHints(hypothesisColHints))
+
+	_, deds, err := solver.ApplyMany(game)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 仮定の検証中の推論は通知されず、確定した推論だけが届く
+	if count != len(deds) {
+		t.Errorf("expected %v, got %v", len(deds), count)
+	}
+}
+
+func TestObservers(t *testing.T) {
+	var first, second []string
+	var count int
+	solver := picrosssolver.NewSolver(
+		picrosssolver.WithObserver(recordingObserver{&first}),
+		picrosssolver.WithObserver(picrosssolver.Observers(recordingObserver{&second}, nil, countingObserver{count: &count})),
+	)
+	game, _ := picrosssolver.NewGame(ParseHints("0 2"), ParseHints("1 1"))
+
+	deds, err := solver.ApplyOnce(game)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(first) == 0 || !reflect.DeepEqual(first, second) {
+		t.Errorf("expected same events, got %v and %v", first, second)
+	}
+	if count != len(deds) {
+		t.Errorf("expected %v, got %v", len(deds), count)
+	}
+	if picrosssolver.Observers() != nil || picrosssolver.Observers(nil) != nil {
+		t.Errorf("expected nil observer")
+	}
+}
+
+func TestObserverQueued(t *testing.T) {
+	var queued, many []string
+	game, _ := picrosssolver.NewGame(ParseHints(hypothesisRowHints), ParseHints(hypothesisColHints))
+	picrosssolver.NewSolver(picrosssolver.WithHypothesis(), picrosssolver.WithObserver(recordingObserver{&many})).ApplyMany(game)
+	game, _ = picrosssolver.NewGame(ParseHints(hypothesisRowHints), ParseHints(hypothesisColHints))
+
+	picrosssolver.NewSolver(picrosssolver.WithHypothesis(), picrosssolver.WithObserver(recordingObserver{&queued})).ApplyManyQueued(game)
+
+	// 評価する行・列は減るが、推論の通知は ApplyMany と一致する
+	filter := func(events []string) []string {
+		var deds []string
+		for _, e := range events {
+			if e[:3] == "ded" {
+				deds = append(deds, e)
+			}
+		}
+		return deds
+	}
+	if !reflect.DeepEqual(filter(many), filter(queued)) {
+		t.Errorf("expected %v, got %v", filter(many), filter(queued))
+	}
+}
+
+func TestObserverPassNumbers(t *testing.T) {
+	var events []string
+	solver := picrosssolver.NewSolver(picrosssolver.WithObserver(recordingObserver{&events}))
+	game, _ := picrosssolver.NewGame(ParseHints("0 2"), ParseHints("1 1"))
+
+	// ApplyOnce と ApplyMany のどちらも、同じ game の続きのパスとして通知される
+	solver.ApplyOnce(game)
+	solver.ApplyOnce(game)
+	solver.ApplyMany(game)
+	solver.ApplyOnce(game)
+
+	var passes []string
+	for _, e := range events {
+		if e[:3] != "lin" && e[:3] != "ded" {
+			passes = append(passes, e)
+		}
+	}
+	expected := []string{"start 0", "end 0 true", "start 1", "end 1 false", "start 2", "end 2 false", "start 3", "end 3 false"}
+	if !reflect.DeepEqual(expected, passes) {
+		t.Errorf("expected %v, got %v", expected, passes)
+	}
+}
+
+func TestObserverNoAlloc(t *testing.T) {
+	rowHints, colHints := ParseHints(hypothesisRowHints), ParseHints(hypothesisColHints)
+	allocs := func(solver picrosssolver.Solver) float64 {
+		return testing.AllocsPerRun(10, func() {
+			game, _ := picrosssolver.NewGame(rowHints, colHints)
+			solver.ApplyMany(game)
+		})
+	}
+
+	// 通知そのものは割り当てをしないので、Observer が無いときと割り当ての数は同じ
+	var count int
+	without := allocs(picrosssolver.NewSolver())
+	with := allocs(picrosssolver.NewSolver(picrosssolver.WithObserver(countingObserver{count: &count})))
+	if count == 0 {
+		t.Fatalf("expected observer to be notified")
+	}
+	if with != without {
+		t.Errorf("expected %v allocs, got %v", without, with)
+	}
+}
+
+func BenchmarkObserver(b *testing.B) {
+	rowHints, colHints := ParseHints(hypothesisRowHints), ParseHints(hypothesisColHints)
+	var count int
+	solvers := map[string]picrosssolver.Solver{
+		"none":     picrosssolver.NewSolver(),
+		"counting": picrosssolver.NewSolver(picrosssolver.WithObserver(countingObserver{count: &count})),
+	}
+	for _, name := range []string{"none", "counting"} {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				game, _ := picrosssolver.NewGame(rowHints, colHints)
+				solvers[name].ApplyMany(game)
+			}
+		})
+	}
+}
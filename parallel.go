@@ -0,0 +1,59 @@
+package picrosssolver
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// 1パスの中で行を n 個のワーカーで並行に評価し、すべて反映してから列も同様に評価する。
+// 結果は番号順に反映するので、盤面と推論の履歴は逐次のときと一致する。1 以下なら逐次
+func WithWorkers(n int) Option {
+	return func(s *Solver) {
+		s.workers = max(n, 1)
+	}
+}
+
+func (s Solver) applyOnceParallel(ctx context.Context, game *Game) (deds []Deduction, err error) {
+	for _, kind := range []LineKind{LineKindRow, LineKindColumn} {
+		count := len(game.rowHints)
+		if kind == LineKindColumn {
+			count = len(game.colHints)
+		}
+		results := make([]lineResult, count)
+		// 取り消されて評価しなかった行・列
+		skipped := make([]bool, count)
+
+		var next atomic.Int64
+		var wg sync.WaitGroup
+		for range min(s.workers, count) {
+			wg.Go(func() {
+				for {
+					i := int(next.Add(1)) - 1
+					if i >= count {
+						return
+					}
+					if ctx.Err() != nil {
+						skipped[i] = true
+						continue
+					}
+					// 反映するまで結果のセルを持つので、行・列ごとに領域を確保する
+					results[i] = s.evaluateLine(game, LineRef{kind, i}, nil)
+				}
+			})
+		}
+		wg.Wait()
+
+		for i, result := range results {
+			if skipped[i] {
+				return deds, ctx.Err()
+			}
+			lineDeds, err := s.commitLine(game, LineRef{kind, i}, result)
+			if err != nil {
+				return deds, err
+			}
+			deds = append(deds, lineDeds...)
+		}
+	}
+	return deds, nil
+}
@@ -0,0 +1,100 @@
+package picrosssolver_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	picrosssolver "github.com/inahym196/picross-solver"
+)
+
+func TestWithWorkers(t *testing.T) {
+	tests := []struct {
+		rowHints string
+		colHints string
+	}{
+		{"0 2", "1 1"},
+		{hypothesisRowHints, hypothesisColHints},
+		{"1 1", "1 1"},
+		{"2 2", "2 0 2"},
+		{"1-1 0 1", "2 0 1"},
+		{
+			largeRowHints,
+			largeColHints,
+		},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("case%d", i), func(t *testing.T) {
+			var wantEvents []string
+			game, _ := picrosssolver.NewGame(ParseHints(tt.rowHints), ParseHints(tt.colHints))
+			wantN, wantDeds, wantErr := picrosssolver.NewSolver(picrosssolver.WithHypothesis(), picrosssolver.WithObserver(recordingObserver{&wantEvents})).ApplyMany(game)
+			want := game.PrintBoard()
+
+			for _, workers := range []int{2, 3, 8} {
+				var events []string
+				game, _ := picrosssolver.NewGame(ParseHints(tt.rowHints), ParseHints(tt.colHints))
+				solver := picrosssolver.NewSolver(picrosssolver.WithHypothesis(), picrosssolver.WithWorkers(workers), picrosssolver.WithObserver(recordingObserver{&events}))
+
+				n, deds, err := solver.ApplyMany(game)
+
+				if n != wantN || !reflect.DeepEqual(wantDeds, deds) || fmt.Sprint(wantErr) != fmt.Sprint(err) {
+					t.Errorf("workers=%d: expected %d %v %v, got %d %v %v", workers, wantN, wantDeds, wantErr, n, deds, err)
+				}
+				if got := game.PrintBoard(); !reflect.DeepEqual(want, got) {
+					t.Errorf("workers=%d: expected %v, got %v", workers, want, got)
+				}
+				if !reflect.DeepEqual(wantEvents, events) {
+					t.Errorf("workers=%d: expected same observer events", workers)
+				}
+			}
+		})
+	}
+}
+
+func TestWithWorkersCanceled(t *testing.T) {
+	game, _ := picrosssolver.NewGame(ParseHints("0 2"), ParseHints("1 1"))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, deds, err := picrosssolver.NewSolver(picrosssolver.WithWorkers(4)).ApplyManyContext(ctx, game)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if len(deds) != 0 {
+		t.Errorf("expected no deductions, got %v", deds)
+	}
+}
+
+func TestWithWorkersCache(t *testing.T) {
+	rowHints := ParseHints(largeRowHints)
+	colHints := ParseHints(largeColHints)
+	solver := picrosssolver.NewSolver(picrosssolver.WithWorkers(4), picrosssolver.WithLineCache(1024))
+
+	// キャッシュはワーカーの間で共有される
+	for range 2 {
+		game, _ := picrosssolver.NewGame(rowHints, colHints)
+		if _, _, err := solver.ApplyMany(game); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !game.IsSolved() {
+			t.Fatalf("expected solved board")
+		}
+	}
+	if stats := solver.CacheStats(); stats.Hits == 0 {
+		t.Errorf("expected cache hits, got %+v", stats)
+	}
+}
+
+func BenchmarkE2EParallel(b *testing.B) {
+	rowHints := ParseHints(largeRowHints)
+	colHints := ParseHints(largeColHints)
+	solver := picrosssolver.NewSolver(picrosssolver.WithWorkers(4))
+
+	for b.Loop() {
+		game, _ := picrosssolver.NewGame(rowHints, colHints)
+		solver.ApplyMany(game)
+	}
+}
@@ -0,0 +1,219 @@
+package picrosssolver
+
+import "slices"
+
+// [0] はブロックなしとして扱う
+func blockHints(hints []int) []int {
+	if len(hints) == 1 && hints[0] == 0 {
+		return nil
+	}
+	return hints
+}
+
+func canBeWhite(c Cell) bool {
+	return c == CellUndetermined || c == CellWhite
+}
+
+func canBeColor(c Cell, color Color) bool {
+	return c == CellUndetermined || c == ColorCell(color)
+}
+
+// 埋まった行・列のブロックがヒントどおりか。配置の表を作らずに調べる
+func filledMatches(cells []Cell, hints []int, colors []Color) bool {
+	hints = blockHints(hints)
+	j := 0
+	for i := 0; i < len(cells); {
+		c := cells[i]
+		if c == CellWhite {
+			i++
+			continue
+		}
+		start := i
+		for i < len(cells) && cells[i] == c {
+			i++
+		}
+		color := ColorBlack
+		if colors != nil && j < len(colors) {
+			color = colors[j]
+		}
+		if j == len(hints) || hints[j] != i-start || c != ColorCell(color) {
+			return false
+		}
+		j++
+	}
+	return j == len(hints)
+}
+
+// ヒントの配置を数え上げるための表
+type placement struct {
+	cells  []Cell
+	hints  []int
+	colors []Color
+	// 色ごとの、cells[:i] のうちその色を置けないセルの数
+	blocked []colorPrefix
+	// blockIndex[j]: ブロック j の色の blocked での位置
+	blockIndex []int
+}
+
+type colorPrefix struct {
+	color  Color
+	counts []int
+}
+
+func newPlacement(cells []Cell, hints []int, colors []Color) placement {
+	hints = blockHints(hints)
+	if len(hints) == 0 {
+		colors = nil
+	}
+	p := placement{cells: cells, hints: hints, colors: colors, blockIndex: make([]int, len(hints))}
+	for j := range hints {
+		color := p.colorOf(j)
+		index := slices.IndexFunc(p.blocked, func(b colorPrefix) bool { return b.color == color })
+		if index == -1 {
+			counts := make([]int, len(cells)+1)
+			for i, c := range cells {
+				counts[i+1] = counts[i]
+				if !canBeColor(c, color) {
+					counts[i+1]++
+				}
+			}
+			index = len(p.blocked)
+			p.blocked = append(p.blocked, colorPrefix{color, counts})
+		}
+		p.blockIndex[j] = index
+	}
+	return p
+}
+
+func (p placement) colorOf(j int) Color {
+	if p.colors == nil {
+		return ColorBlack
+	}
+	return p.colors[j]
+}
+
+// ブロック j を start から置けるなら終端を返す
+func (p placement) blockEnd(j, start int) (int, bool) {
+	end := start + p.hints[j]
+	if end > len(p.cells) {
+		return 0, false
+	}
+	blocked := p.blocked[p.blockIndex[j]].counts
+	return end, blocked[end] == blocked[start]
+}
+
+// ブロック j を end で終えたとき、次のブロックを置き始められる位置。
+// 同じ色が続くときだけ間に白が要る
+func (p placement) nextStart(j, end int) (int, bool) {
+	if j+1 == len(p.hints) || p.colorOf(j) != p.colorOf(j+1) {
+		return end, true
+	}
+	if end >= len(p.cells) || !canBeWhite(p.cells[end]) {
+		return 0, false
+	}
+	return end + 1, true
+}
+
+// rows x cols の表。行ごとに確保せず、ひとつの配列を切り分ける
+func boolTable(rows, cols int) [][]bool {
+	backing := make([]bool, rows*cols)
+	table := make([][]bool, rows)
+	for i := range table {
+		table[i] = backing[i*cols : (i+1)*cols : (i+1)*cols]
+	}
+	return table
+}
+
+// fits[i][j]: hints[j:] を cells[i:] に配置できるか
+func (p placement) suffixFits() [][]bool {
+	n, k := len(p.cells), len(p.hints)
+
+	fits := boolTable(n+1, k+1)
+	fits[n][k] = true
+
+	for i := n - 1; i >= 0; i-- {
+		for j := k; j >= 0; j-- {
+			if canBeWhite(p.cells[i]) && fits[i+1][j] {
+				fits[i][j] = true
+				continue
+			}
+			if j == k {
+				continue
+			}
+			end, ok := p.blockEnd(j, i)
+			if !ok {
+				continue
+			}
+			if next, ok := p.nextStart(j, end); ok {
+				fits[i][j] = fits[next][j+1]
+			}
+		}
+	}
+	return fits
+}
+
+func suffixPlaceable(cells []Cell, hints []int, colors []Color) [][]bool {
+	return newPlacement(cells, hints, colors).suffixFits()
+}
+
+// 矛盾しない全配置を走査し、各セルが取りうる値の集合を返す。配置が無ければ nil
+func placementCandidates(cells []Cell, hints []int, colors []Color) []cellSet {
+	p := newPlacement(cells, hints, colors)
+	fits := p.suffixFits()
+	if !fits[0][0] {
+		return nil
+	}
+	n, k := len(p.cells), len(p.hints)
+
+	// reach[i][j]: hints[:j] を cells[:i] に置き終え、i から次を置ける
+	reach := boolTable(n+1, k+1)
+	reach[0][0] = true
+
+	possible := make([]cellSet, n)
+	// 色ごとのブロックの区間は p.blocked と同じ並びの差分で記録する
+	colorDiff := make([][]int, len(p.blocked))
+	for i := range colorDiff {
+		colorDiff[i] = make([]int, n+1)
+	}
+
+	for i := range n {
+		for j := 0; j <= k; j++ {
+			if !reach[i][j] || !fits[i][j] {
+				continue
+			}
+			if canBeWhite(cells[i]) && fits[i+1][j] {
+				possible[i] |= cellBit(CellWhite)
+				reach[i+1][j] = true
+			}
+			if j == k {
+				continue
+			}
+			end, ok := p.blockEnd(j, i)
+			if !ok {
+				continue
+			}
+			next, ok := p.nextStart(j, end)
+			if !ok || !fits[next][j+1] {
+				continue
+			}
+			diff := colorDiff[p.blockIndex[j]]
+			diff[i]++
+			diff[end]--
+			if next > end {
+				possible[end] |= cellBit(CellWhite)
+			}
+			reach[next][j+1] = true
+		}
+	}
+
+	for c, diff := range colorDiff {
+		depth := 0
+		for i := range n {
+			depth += diff[i]
+			if depth > 0 {
+				possible[i] |= cellBit(ColorCell(p.blocked[c].color))
+			}
+		}
+	}
+	return possible
+}
@@ -0,0 +1,113 @@
+package picrosssolver
+
+import "context"
+
+// ApplyManyQueued の評価回数
+type QueueStats struct {
+	// ApplyMany の反復回数に相当
+	Passes int
+	// DeduceLine を呼んだ行・列の数
+	Evaluations int
+	// 毎回すべての行・列を走査する場合に比べて省いた数
+	Skipped int
+}
+
+// 推論で値が変わったセルの位置
+func changedPositions(before, after []Cell) []int {
+	var changed []int
+	for i := range before {
+		if before[i] != after[i] {
+			changed = append(changed, i)
+		}
+	}
+	return changed
+}
+
+// ApplyMany と同じ順序で推論するが、前回の評価からセルが変わった行・列だけを評価し直す。
+// 盤面と推論の履歴は ApplyMany と一致する
+func (s Solver) ApplyManyQueued(game *Game) (QueueStats, []Deduction, error) {
+	return s.ApplyManyQueuedContext(context.Background(), game)
+}
+
+// ApplyManyQueued と同じだが、ApplyManyContext と同じく ctx と反復・推論の上限を確かめ、
+// 打ち切ったらそれまでの推論とその理由を返す
+func (s Solver) ApplyManyQueuedContext(ctx context.Context, game *Game) (QueueStats, []Deduction, error) {
+	dirty := map[LineKind][]bool{
+		LineKindRow:    make([]bool, len(game.rowHints)),
+		LineKindColumn: make([]bool, len(game.colHints)),
+	}
+	for _, lines := range dirty {
+		for i := range lines {
+			lines[i] = true
+		}
+	}
+	crossing := map[LineKind]LineKind{LineKindRow: LineKindColumn, LineKindColumn: LineKindRow}
+
+	var stats QueueStats
+	var deds []Deduction
+	cursor := 0
+	buf := newLineBuffer(game)
+	for {
+		if err := ctx.Err(); err != nil {
+			return stats, deds, err
+		}
+		if err := s.checkLimits(game, stats.Passes, len(deds)); err != nil {
+			return stats, deds, err
+		}
+		pass := s.startPass(game)
+		changed := false
+		for _, kind := range []LineKind{LineKindRow, LineKindColumn} {
+			for i := range dirty[kind] {
+				if !dirty[kind][i] {
+					stats.Skipped++
+					continue
+				}
+				if err := ctx.Err(); err != nil {
+					s.notifyPassEnd(pass, changed)
+					return stats, deds, err
+				}
+				dirty[kind][i] = false
+				stats.Evaluations++
+
+				lineDeds, err := s.applyLine(game, LineRef{kind, i}, buf)
+				deds = append(deds, lineDeds...)
+				if err != nil {
+					s.notifyPassEnd(pass, changed || len(lineDeds) > 0)
+					return stats, deds, err
+				}
+				if len(lineDeds) == 0 {
+					continue
+				}
+				changed = true
+				// 自身も続けて推論できる可能性があるので評価し直す
+				dirty[kind][i] = true
+				for _, j := range changedPositions(lineDeds[0].before, lineDeds[len(lineDeds)-1].after) {
+					dirty[crossing[kind]][j] = true
+				}
+			}
+		}
+		if changed {
+			s.notifyPassEnd(pass, true)
+			stats.Passes++
+			continue
+		}
+
+		if err := s.checkSolvable(game); err != nil {
+			s.notifyPassEnd(pass, false)
+			return stats, deds, err
+		}
+		hypoDeds, err := s.hypothesize(ctx, game, &cursor)
+		s.notifyPassEnd(pass, len(hypoDeds) > 0)
+		if err != nil || len(hypoDeds) == 0 {
+			return stats, deds, err
+		}
+		deds = append(deds, hypoDeds...)
+		for _, ded := range hypoDeds {
+			dirty[ded.lineRef.Kind][ded.lineRef.Index] = true
+			for _, j := range changedPositions(ded.before, ded.after) {
+				dirty[crossing[ded.lineRef.Kind]][j] = true
+			}
+		}
+		stats.Passes++
+	}
+}
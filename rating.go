@@ -0,0 +1,131 @@
+package picrosssolver
+
+import "context"
+
+type Difficulty uint8
+
+const (
+	DifficultyEasy Difficulty = iota
+	DifficultyMedium
+	DifficultyHard
+	DifficultyExpert
+)
+
+func (d Difficulty) String() string {
+	switch d {
+	case DifficultyEasy:
+		return "Easy"
+	case DifficultyMedium:
+		return "Medium"
+	case DifficultyHard:
+		return "Hard"
+	case DifficultyExpert:
+		return "Expert"
+	default:
+		panic("invalid difficulty")
+	}
+}
+
+// ルールごとの重み。ここに無いルールは ruleWeightDefault とする
+var ruleWeights = map[string]int{
+	"ZeroHintRule":               1,
+	"MinimumSpacingRule":         1,
+	"FillRemainingWhiteRule":     1,
+	"OverlapFillRule":            2,
+	"OverlapExpansionRule":       2,
+	"EdgeExpansionRule":          2,
+	"BlockSatisfiedRule":         2,
+	"PruneImpossibleSegmentRule": 2,
+	"ExhaustivePlacementRule":    4,
+}
+
+const (
+	ruleWeightDefault = 3
+	probeWeight       = 20
+	branchWeight      = 50
+	// これ未満の Score で、仮定も分岐も要らなければ Easy
+	easyScoreLimit = 15
+)
+
+type Rating struct {
+	Difficulty Difficulty
+	Score      int
+	// ApplyMany の反復回数
+	Passes int
+	// ルール名ごとの推論の件数
+	Rules map[string]int
+	// 推論につながった仮定の数。1つの仮定が複数の行を確定させても1と数える
+	Probes int
+	// ApplyMany で解けなかった盤面を解くのに要した探索の分岐の数
+	Branches int
+}
+
+// 人が解く難しさを評価する。使ったルールの重み、反復回数、仮定と分岐の数を合計し、
+// 分岐が要れば Expert、仮定が要れば Hard、それ以外は Score で Easy と Medium に分ける。
+// game は変更しない
+func (s Solver) Rate(game *Game) (Rating, error) {
+	return s.RateContext(context.Background(), game)
+}
+
+// Rate と同じだが、ctx が取り消されたら評価を打ち切り ctx.Err() を返す
+func (s Solver) RateContext(ctx context.Context, game *Game) (Rating, error) {
+	// 仮定が要るかで Hard を見分けるので、WithHypothesis が無くても仮定を試す
+	s.probing = true
+	// 評価のための試し解きなので進行は通知しない
+	s.observer = nil
+	probe := game.clone()
+	n, deds, err := s.ApplyManyContext(ctx, probe)
+	if err != nil {
+		return Rating{}, err
+	}
+
+	rating := Rating{Passes: n, Rules: map[string]int{}}
+	// 同じ仮定の推論は続けて並ぶ
+	var last *Probe
+	for _, ded := range deds {
+		rating.Rules[ded.ruleName]++
+		if ded.probe != nil && ded.probe != last {
+			rating.Probes++
+			last = ded.probe
+		}
+	}
+
+	if !probe.IsSolved() {
+		var stats searchStats
+		found := false
+		if _, err := s.search(ctx, probe, func(Board) bool {
+			found = true
+			return false
+		}, &stats); err != nil {
+			return Rating{}, err
+		}
+		if !found {
+			return Rating{}, ErrNoSolution
+		}
+		rating.Branches = stats.branches
+	}
+
+	rating.Score = rating.Passes + rating.Probes*probeWeight + rating.Branches*branchWeight
+	for name := range rating.Rules {
+		if name == s.hypothesis.Name() {
+			continue
+		}
+		weight, ok := ruleWeights[name]
+		if !ok {
+			weight = ruleWeightDefault
+		}
+		rating.Score += weight
+	}
+
+	switch {
+	case rating.Branches > 0:
+		rating.Difficulty = DifficultyExpert
+	case rating.Probes > 0:
+		rating.Difficulty = DifficultyHard
+	case rating.Score >= easyScoreLimit:
+		rating.Difficulty = DifficultyMedium
+	default:
+		rating.Difficulty = DifficultyEasy
+	}
+	return rating, nil
+}
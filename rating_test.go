@@ -0,0 +1,91 @@
+package picrosssolver_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	picrosssolver "github.com/inahym196/picross-solver"
+)
+
+func TestRate(t *testing.T) {
+	tests := []struct {
+		rowHints [][]int
+		colHints [][]int
+		expected picrosssolver.Difficulty
+	}{
+		{ParseHints("0 2"), ParseHints("1 1"), picrosssolver.DifficultyEasy},
+		{ParseHints("5 1-1 1-1 1-1 1-2"), ParseHints("1 5 1 5 1-1"), picrosssolver.DifficultyEasy},
+		{ParseHints(largeRowHints), ParseHints(largeColHints), picrosssolver.DifficultyMedium},
+		{ParseHints(hypothesisRowHints), ParseHints(hypothesisColHints), picrosssolver.DifficultyHard},
+		{ParseHints("1 1"), ParseHints("1 1"), picrosssolver.DifficultyExpert},
+	}
+	solver := picrosssolver.NewSolver()
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("case%d", i), func(t *testing.T) {
+			game, _ := picrosssolver.NewGame(tt.rowHints, tt.colHints)
+
+			rating, err := solver.Rate(game)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if rating.Difficulty != tt.expected {
+				t.Errorf("expected %s, got %s (%+v)", tt.expected, rating.Difficulty, rating)
+			}
+			again, _ := solver.Rate(game)
+			if !reflect.DeepEqual(rating, again) {
+				t.Errorf("expected deterministic rating, got %+v and %+v", rating, again)
+			}
+			if game.PrintBoard()[0][0] != '?' {
+				t.Errorf("Rate mutated game: %v", game.PrintBoard())
+			}
+		})
+	}
+}
+
+func TestRateContradiction(t *testing.T) {
+	game, _ := picrosssolver.NewGame(ParseHints("2 2"), ParseHints("2 0 2"))
+
+	if _, err := picrosssolver.NewSolver().Rate(game); !errors.Is(err, picrosssolver.ErrContradiction) {
+		t.Errorf("expected ErrContradiction, got %v", err)
+	}
+}
+
+func TestRateProbes(t *testing.T) {
+	var count int
+	solver := picrosssolver.NewSolver(picrosssolver.WithObserver(countingObserver{count: &count}))
+	game, _ := picrosssolver.NewGame(ParseHints(hypothesisRowHints), ParseHints(hypothesisColHints))
+
+	rating, err := solver.Rate(game)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 評価のための試し解きは Observer に通知しない
+	if count != 0 {
+		t.Errorf("expected no notifications, got %v", count)
+	}
+	// 推論の件数ではなく、推論につながった仮定の数を数える
+	game, _ = picrosssolver.NewGame(ParseHints(hypothesisRowHints), ParseHints(hypothesisColHints))
+	_, deds, _ := picrosssolver.NewSolver(picrosssolver.WithHypothesis()).ApplyMany(game)
+	var probes int
+	var last string
+	for _, ded := range deds {
+		probe, ok := ded.Probe()
+		if !ok {
+			continue
+		}
+		if probe.String() != last {
+			probes++
+			last = probe.String()
+		}
+	}
+	if rating.Probes != probes {
+		t.Errorf("expected %v, got %v", probes, rating.Probes)
+	}
+	if rating.Probes >= rating.Rules["HypothesisRule"] {
+		t.Errorf("expected fewer probes than deductions %v, got %v", rating.Rules["HypothesisRule"], rating.Probes)
+	}
+}
@@ -0,0 +1,155 @@
+// render は盤面をヒント付きで画像や端末に描く
+package render
+
+import (
+	"image/color"
+	"strconv"
+
+	picrosssolver "github.com/inahym196/picross-solver"
+)
+
+const defaultCellSize = 16
+
+type Options struct {
+	// 1セルの画素数。0 なら 16
+	CellSize int
+	// nil でなければ、この推論の行・列と値が変わったセルを強調する
+	Highlight *picrosssolver.Deduction
+}
+
+// 黒以外の色の塗り。番号が範囲を超えたら繰り返す
+var palette = []color.RGBA{
+	{0xd6, 0x27, 0x28, 0xff},
+	{0x1f, 0x77, 0xb4, 0xff},
+	{0x2c, 0xa0, 0x2c, 0xff},
+	{0xff, 0x7f, 0x0e, 0xff},
+	{0x94, 0x67, 0xbd, 0xff},
+	{0x8c, 0x56, 0x4b, 0xff},
+	{0xe3, 0x77, 0xc2, 0xff},
+	{0x17, 0xbe, 0xcf, 0xff},
+}
+
+var (
+	colorBackground   = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	colorBlack        = color.RGBA{0x20, 0x20, 0x20, 0xff}
+	colorUndetermined = color.RGBA{0xdd, 0xdd, 0xdd, 0xff}
+	colorGrid         = color.RGBA{0x99, 0x99, 0x99, 0xff}
+	colorGuide        = color.RGBA{0x20, 0x20, 0x20, 0xff}
+	colorHintText     = color.RGBA{0x20, 0x20, 0x20, 0xff}
+	colorLineTint     = color.RGBA{0xff, 0xf3, 0xb0, 0xff}
+	colorChanged      = color.RGBA{0xe0, 0x30, 0x30, 0xff}
+)
+
+func cellColor(c picrosssolver.Cell) color.RGBA {
+	switch {
+	case c == picrosssolver.CellUndetermined:
+		return colorUndetermined
+	case c == picrosssolver.CellWhite:
+		return colorBackground
+	case c.Color() == picrosssolver.ColorBlack:
+		return colorBlack
+	default:
+		return palette[int(c.Color()-1)%len(palette)]
+	}
+}
+
+// ヒントの文字列と色
+type hintLabel struct {
+	text  string
+	color color.RGBA
+}
+
+// 盤面とヒントの配置
+type layout struct {
+	cell      int
+	board     picrosssolver.Board
+	rowLabels [][]hintLabel
+	colLabels [][]hintLabel
+	// 盤面の左上の座標
+	left, top int
+	width     int
+	height    int
+	highlight *picrosssolver.Deduction
+	changed   map[[2]int]bool
+}
+
+func labels(game *picrosssolver.Game, kind picrosssolver.LineKind, hintsList [][]int) [][]hintLabel {
+	result := make([][]hintLabel, len(hintsList))
+	for i, hints := range hintsList {
+		colors := game.LineColors(picrosssolver.LineRef{Kind: kind, Index: i})
+		for j, h := range hints {
+			c := colorHintText
+			if colors != nil && h > 0 {
+				c = cellColor(picrosssolver.ColorCell(colors[j]))
+			}
+			result[i] = append(result[i], hintLabel{strconv.Itoa(h), c})
+		}
+	}
+	return result
+}
+
+func newLayout(game *picrosssolver.Game, opts Options) layout {
+	cell := opts.CellSize
+	if cell <= 0 {
+		cell = defaultCellSize
+	}
+	l := layout{
+		cell:      cell,
+		board:     game.Board(),
+		rowLabels: labels(game, picrosssolver.LineKindRow, game.RowHints()),
+		colLabels: labels(game, picrosssolver.LineKindColumn, game.ColHints()),
+		highlight: opts.Highlight,
+		changed:   map[[2]int]bool{},
+	}
+	maxRow, maxCol := 0, 0
+	for _, ls := range l.rowLabels {
+		maxRow = max(maxRow, len(ls))
+	}
+	for _, ls := range l.colLabels {
+		maxCol = max(maxCol, len(ls))
+	}
+	l.left, l.top = maxRow*cell, maxCol*cell
+	l.width = l.left + l.board.GetColumns()*cell + 1
+	l.height = l.top + l.board.GetRows()*cell + 1
+
+	if ded := opts.Highlight; ded != nil {
+		for _, k := range ded.ChangedCells() {
+			l.changed[l.cellOf(ded.Line(), k)] = true
+		}
+	}
+	return l
+}
+
+// 行・列の k 番目のセルの (row, col)
+func (l layout) cellOf(ref picrosssolver.LineRef, k int) [2]int {
+	if ref.Kind == picrosssolver.LineKindRow {
+		return [2]int{ref.Index, k}
+	}
+	return [2]int{k, ref.Index}
+}
+
+// 強調する推論の行・列に含まれるか
+func (l layout) onHighlightLine(row, col int) bool {
+	if l.highlight == nil {
+		return false
+	}
+	ref := l.highlight.Line()
+	if ref.Kind == picrosssolver.LineKindRow {
+		return ref.Index == row
+	}
+	return ref.Index == col
+}
+
+// 行・列のヒントの i 番目を描く区画の左上
+func (l layout) rowLabelOrigin(row, i int) (int, int) {
+	return l.left - (len(l.rowLabels[row])-i)*l.cell, l.top + row*l.cell
+}
+
+func (l layout) colLabelOrigin(col, i int) (int, int) {
+	return l.left + col*l.cell, l.top - (len(l.colLabels[col])-i)*l.cell
+}
+
+// 5セルごとの区切り線か
+func isGuide(i, n int) bool {
+	return i%5 == 0 || i == n
+}
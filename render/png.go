@@ -0,0 +1,111 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+
+	picrosssolver "github.com/inahym196/picross-solver"
+)
+
+// 3x5 の数字。各行の下位3bitが左から右の画素
+var digitFont = [10][5]uint8{
+	{7, 5, 5, 5, 7},
+	{2, 6, 2, 2, 7},
+	{7, 1, 7, 4, 7},
+	{7, 1, 7, 1, 7},
+	{5, 5, 7, 1, 1},
+	{7, 4, 7, 1, 7},
+	{7, 4, 7, 5, 7},
+	{7, 1, 1, 1, 1},
+	{7, 5, 7, 5, 7},
+	{7, 5, 7, 1, 7},
+}
+
+func fill(img *image.RGBA, x, y, w, h int, c color.RGBA) {
+	draw.Draw(img, image.Rect(x, y, x+w, y+h), image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+// 区画 (x, y, size) の中央に数字列を描く
+func drawText(img *image.RGBA, x, y, size int, text string, c color.RGBA) {
+	scale := max(1, size/(4*len(text)+2))
+	width := (4*len(text) - 1) * scale
+	x += (size - width) / 2
+	y += (size - 5*scale) / 2
+	for _, r := range text {
+		glyph := digitFont[r-'0']
+		for row, bits := range glyph {
+			for col := range 3 {
+				if bits&(4>>col) != 0 {
+					fill(img, x+col*scale, y+row*scale, scale, scale, c)
+				}
+			}
+		}
+		x += 4 * scale
+	}
+}
+
+func drawPNG(l layout) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, l.width, l.height))
+	fill(img, 0, 0, l.width, l.height, colorBackground)
+
+	for row, labels := range l.rowLabels {
+		for i, label := range labels {
+			x, y := l.rowLabelOrigin(row, i)
+			drawText(img, x, y, l.cell, label.text, label.color)
+		}
+	}
+	for col, labels := range l.colLabels {
+		for i, label := range labels {
+			x, y := l.colLabelOrigin(col, i)
+			drawText(img, x, y, l.cell, label.text, label.color)
+		}
+	}
+
+	for i := range l.board {
+		for j, c := range l.board[i] {
+			x, y := l.left+j*l.cell, l.top+i*l.cell
+			fillColor := cellColor(c)
+			if l.onHighlightLine(i, j) && c != picrosssolver.CellBlack && !c.IsColored() {
+				fillColor = colorLineTint
+			}
+			fill(img, x, y, l.cell, l.cell, fillColor)
+			if c == picrosssolver.CellWhite {
+				// 白確定のセルは中央に点を打って未確定と区別する
+				fill(img, x+l.cell/2-1, y+l.cell/2-1, 2, 2, colorGrid)
+			}
+		}
+	}
+
+	rows, cols := l.board.GetRows(), l.board.GetColumns()
+	for i := 0; i <= rows; i++ {
+		c := colorGrid
+		if isGuide(i, rows) {
+			c = colorGuide
+		}
+		fill(img, l.left, l.top+i*l.cell, cols*l.cell+1, 1, c)
+	}
+	for j := 0; j <= cols; j++ {
+		c := colorGrid
+		if isGuide(j, cols) {
+			c = colorGuide
+		}
+		fill(img, l.left+j*l.cell, l.top, 1, rows*l.cell+1, c)
+	}
+
+	for cell := range l.changed {
+		x, y := l.left+cell[1]*l.cell, l.top+cell[0]*l.cell
+		fill(img, x, y, l.cell+1, 2, colorChanged)
+		fill(img, x, y+l.cell-1, l.cell+1, 2, colorChanged)
+		fill(img, x, y, 2, l.cell+1, colorChanged)
+		fill(img, x+l.cell-1, y, 2, l.cell+1, colorChanged)
+	}
+	return img
+}
+
+// 盤面をヒントと5セルごとの区切り線付きで PNG に描く
+func PNG(w io.Writer, game *picrosssolver.Game, opts Options) error {
+	return png.Encode(w, drawPNG(newLayout(game, opts)))
+}
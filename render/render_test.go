@@ -0,0 +1,173 @@
+package render_test
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	picrosssolver "github.com/inahym196/picross-solver"
+	"github.com/inahym196/picross-solver/render"
+)
+
+func newGame(t *testing.T, rows, cols [][]int) *picrosssolver.Game {
+	t.Helper()
+	game, err := picrosssolver.NewGame(rows, cols)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return game
+}
+
+func TestPNG(t *testing.T) {
+	tests := []struct {
+		rows, cols    [][]int
+		cellSize      int
+		width, height int
+	}{
+		// ヒントは最大2個なので左と上の余白は2セル
+		{[][]int{{1, 1}, {3}, {1}}, [][]int{{2}, {1, 1}, {2}}, 10, 10*5 + 1, 10*5 + 1},
+		{[][]int{{1}, {0}}, [][]int{{1}, {0}, {0}}, 0, 16*4 + 1, 16*3 + 1},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("case%d", i), func(t *testing.T) {
+			game := newGame(t, tt.rows, tt.cols)
+			var buf bytes.Buffer
+
+			if err := render.PNG(&buf, game, render.Options{CellSize: tt.cellSize}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			img, err := png.Decode(&buf)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := img.Bounds().Dx(); got != tt.width {
+				t.Errorf("expected width %v, got %v", tt.width, got)
+			}
+			if got := img.Bounds().Dy(); got != tt.height {
+				t.Errorf("expected height %v, got %v", tt.height, got)
+			}
+		})
+	}
+}
+
+func TestPNGCells(t *testing.T) {
+	game := newGame(t, [][]int{{3}, {1}}, [][]int{{1}, {2}, {1}})
+	if _, _, err := picrosssolver.NewSolver().ApplyMany(game); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := render.PNG(&buf, game, render.Options{CellSize: 10}); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 左と上の余白は1セル。セルの中心の少し外れを見る
+	at := func(row, col int) color.Color { return img.At(10+col*10+3, 10+row*10+3) }
+	black := color.RGBAModel.Convert(at(0, 0))
+	white := color.RGBAModel.Convert(at(1, 0))
+	if black == white {
+		t.Fatalf("expected black and white cells to differ, got %v", black)
+	}
+	if got := color.RGBAModel.Convert(at(1, 1)); got != black {
+		t.Errorf("expected %v, got %v", black, got)
+	}
+	if got := color.RGBAModel.Convert(at(1, 2)); got != white {
+		t.Errorf("expected %v, got %v", white, got)
+	}
+}
+
+func TestSVG(t *testing.T) {
+	game := newGame(t, [][]int{{3}, {1}}, [][]int{{1}, {2}, {1}})
+	deds, err := picrosssolver.NewSolver().ApplyOnce(game)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+
+	if err := render.SVG(&buf, game, render.Options{CellSize: 10, Highlight: &deds[0]}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	svg := buf.String()
+
+	for _, want := range []string{
+		`<svg xmlns="http://www.w3.org/2000/svg" width="41" height="31"`,
+		`>3</text>`,
+		`>2</text>`,
+		`stroke-width="2"`,
+		"</svg>",
+	} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("expected %q in output", want)
+		}
+	}
+	// 1本目の推論は Row[0] を埋めるので、変わった3セルに枠が付く
+	if got := strings.Count(svg, `fill="none"`); got != 3 {
+		t.Errorf("expected 3, got %v", got)
+	}
+}
+
+func TestTerminal(t *testing.T) {
+	const (
+		black = "\x1b[38;5;232m██\x1b[0m"
+		white = "\x1b[38;5;255m██\x1b[0m"
+		undet = "\x1b[38;5;244m··\x1b[0m"
+	)
+	tests := []struct {
+		rows, cols [][]int
+		solve      bool
+		expected   string
+	}{
+		{[][]int{{0}, {2}}, [][]int{{1}, {1}}, true, "   1 1\n0 " + white + white + "\n2 " + black + black + "\n"},
+		{[][]int{{1, 1}, {1}}, [][]int{{1}, {1}, {1}}, false, "     1 1 1\n1 1 " + undet + undet + undet + "\n  1 " + undet + undet + undet + "\n"},
+		{[][]int{{12}}, [][]int{{1}, {1}, {1}, {1}, {1}, {1}, {1}, {1}, {1}, {1}, {1}, {1}}, false,
+			"   " + strings.Repeat(" 1", 12) + "\n12 " + strings.Repeat(undet, 12) + "\n"},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("case%d", i), func(t *testing.T) {
+			game := newGame(t, tt.rows, tt.cols)
+			if tt.solve {
+				if _, _, err := picrosssolver.NewSolver().ApplyMany(game); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var buf strings.Builder
+
+			if err := render.Terminal(&buf, game, render.Options{}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if buf.String() != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestTerminalHighlight(t *testing.T) {
+	game := newGame(t, [][]int{{0}, {2}}, [][]int{{1}, {1}})
+	deds, err := picrosssolver.NewSolver().ApplyOnce(game)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf strings.Builder
+
+	if err := render.Terminal(&buf, game, render.Options{Highlight: &deds[1]}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	changed := "\x1b[48;5;229m\x1b[38;5;232m▓▓\x1b[0m"
+	expected := "   1 1\n" +
+		"0 \x1b[38;5;255m██\x1b[0m\x1b[38;5;255m██\x1b[0m\n" +
+		"\x1b[1;33m2\x1b[0m " + changed + changed + "\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
@@ -0,0 +1,84 @@
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+	"strings"
+
+	picrosssolver "github.com/inahym196/picross-solver"
+)
+
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// 盤面をヒントと5セルごとの区切り線付きで SVG に描く
+func SVG(w io.Writer, game *picrosssolver.Game, opts Options) error {
+	l := newLayout(game, opts)
+	var b strings.Builder
+
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", l.width, l.height, l.width, l.height)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="%s"/>`+"\n", l.width, l.height, hex(colorBackground))
+
+	fontSize := l.cell * 3 / 5
+	text := func(x, y int, label hintLabel) {
+		fmt.Fprintf(&b, `<text x="%d" y="%d" font-family="monospace" font-size="%d" text-anchor="middle" dominant-baseline="central" fill="%s">%s</text>`+"\n",
+			x+l.cell/2, y+l.cell/2, fontSize, hex(label.color), label.text)
+	}
+	for row, labels := range l.rowLabels {
+		for i, label := range labels {
+			x, y := l.rowLabelOrigin(row, i)
+			text(x, y, label)
+		}
+	}
+	for col, labels := range l.colLabels {
+		for i, label := range labels {
+			x, y := l.colLabelOrigin(col, i)
+			text(x, y, label)
+		}
+	}
+
+	for i := range l.board {
+		for j, c := range l.board[i] {
+			x, y := l.left+j*l.cell, l.top+i*l.cell
+			fillColor := cellColor(c)
+			if l.onHighlightLine(i, j) && c != picrosssolver.CellBlack && !c.IsColored() {
+				fillColor = colorLineTint
+			}
+			fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n", x, y, l.cell, l.cell, hex(fillColor))
+			if c == picrosssolver.CellWhite {
+				fmt.Fprintf(&b, `<circle cx="%d" cy="%d" r="1" fill="%s"/>`+"\n", x+l.cell/2, y+l.cell/2, hex(colorGrid))
+			}
+		}
+	}
+
+	rows, cols := l.board.GetRows(), l.board.GetColumns()
+	line := func(x1, y1, x2, y2 int, guide bool) {
+		c, width := colorGrid, 1
+		if guide {
+			c, width = colorGuide, 2
+		}
+		fmt.Fprintf(&b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="%s" stroke-width="%d"/>`+"\n", x1, y1, x2, y2, hex(c), width)
+	}
+	for i := 0; i <= rows; i++ {
+		line(l.left, l.top+i*l.cell, l.left+cols*l.cell, l.top+i*l.cell, isGuide(i, rows))
+	}
+	for j := 0; j <= cols; j++ {
+		line(l.left+j*l.cell, l.top, l.left+j*l.cell, l.top+rows*l.cell, isGuide(j, cols))
+	}
+
+	for i := range l.board {
+		for j := range l.board[i] {
+			if !l.changed[[2]int{i, j}] {
+				continue
+			}
+			fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="none" stroke="%s" stroke-width="2"/>`+"\n",
+				l.left+j*l.cell+1, l.top+i*l.cell+1, l.cell-2, l.cell-2, hex(colorChanged))
+		}
+	}
+	b.WriteString("</svg>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
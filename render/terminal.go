@@ -0,0 +1,132 @@
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+	"strings"
+
+	picrosssolver "github.com/inahym196/picross-solver"
+)
+
+const (
+	ansiReset     = "\x1b[0m"
+	ansiBlack     = "\x1b[38;5;232m"
+	ansiWhite     = "\x1b[38;5;255m"
+	ansiUndet     = "\x1b[38;5;244m"
+	ansiLineTint  = "\x1b[48;5;229m"
+	ansiHintFocus = "\x1b[1;33m"
+)
+
+func ansiColor(c color.RGBA) string {
+	return fmt.Sprintf("\x1b[38;2;%d;%d;%dm", c.R, c.G, c.B)
+}
+
+// ヒントの文字色。黒のヒントは端末の既定色のまま
+func hintANSI(label hintLabel) string {
+	if label.color == colorHintText {
+		return ""
+	}
+	return ansiColor(label.color)
+}
+
+// 色を付けて書く。色が無ければそのまま
+func paint(b *strings.Builder, ansi, text string) {
+	if ansi == "" {
+		b.WriteString(text)
+		return
+	}
+	b.WriteString(ansi + text + ansiReset)
+}
+
+// セルの文字と色。値が変わったセルは網掛けにする
+func cellANSI(c picrosssolver.Cell, changed bool) (string, string) {
+	glyph := "██"
+	if changed {
+		glyph = "▓▓"
+	}
+	switch {
+	case c == picrosssolver.CellUndetermined:
+		return "··", ansiUndet
+	case c == picrosssolver.CellWhite:
+		return glyph, ansiWhite
+	case c.Color() == picrosssolver.ColorBlack:
+		return glyph, ansiBlack
+	default:
+		return glyph, ansiColor(cellColor(c))
+	}
+}
+
+// 盤面を ANSI の色付きで描く。列のヒントは盤面の上に縦に積み、行のヒントは左に並べる
+func Terminal(w io.Writer, game *picrosssolver.Game, opts Options) error {
+	l := newLayout(game, opts)
+	var b strings.Builder
+
+	// 1セルの幅。列のヒントの桁数に合わせ、最低2文字
+	width := 2
+	for _, labels := range l.colLabels {
+		for _, label := range labels {
+			width = max(width, len(label.text))
+		}
+	}
+	rowTexts := make([]string, len(l.rowLabels))
+	margin := 0
+	for i, labels := range l.rowLabels {
+		texts := make([]string, len(labels))
+		for j, label := range labels {
+			texts[j] = label.text
+		}
+		rowTexts[i] = strings.Join(texts, " ")
+		margin = max(margin, len(rowTexts[i]))
+	}
+
+	focus := func(ref picrosssolver.LineRef) bool {
+		return l.highlight != nil && l.highlight.Line() == ref
+	}
+
+	depth := l.top / l.cell
+	for k := range depth {
+		b.WriteString(strings.Repeat(" ", margin+1))
+		for j, labels := range l.colLabels {
+			i := k - (depth - len(labels))
+			if i < 0 {
+				b.WriteString(strings.Repeat(" ", width))
+				continue
+			}
+			ansi := hintANSI(labels[i])
+			if focus(picrosssolver.LineRef{Kind: picrosssolver.LineKindColumn, Index: j}) {
+				ansi = ansiHintFocus
+			}
+			paint(&b, ansi, fmt.Sprintf("%*s", width, labels[i].text))
+		}
+		b.WriteString("\n")
+	}
+
+	pad := strings.Repeat(" ", width-2)
+	for i := range l.board {
+		labels := l.rowLabels[i]
+		b.WriteString(strings.Repeat(" ", margin-len(rowTexts[i])))
+		if focus(picrosssolver.LineRef{Kind: picrosssolver.LineKindRow, Index: i}) {
+			paint(&b, ansiHintFocus, rowTexts[i])
+		} else {
+			for j, label := range labels {
+				if j > 0 {
+					b.WriteString(" ")
+				}
+				paint(&b, hintANSI(label), label.text)
+			}
+		}
+		b.WriteString(" ")
+		for j, c := range l.board[i] {
+			glyph, fg := cellANSI(c, l.changed[[2]int{i, j}])
+			if l.onHighlightLine(i, j) {
+				fg = ansiLineTint + fg
+			}
+			paint(&b, fg, pad+glyph)
+		}
+		b.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
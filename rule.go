@@ -1,29 +1,96 @@
 package picrosssolver
 
 import (
+	"context"
+	"errors"
+	"iter"
 	"slices"
 )
 
+// 行・列の推論規則。WithLineCache のキャッシュは Name ではなく型と値でルールを見分ける
 type Rule interface {
 	Name() string
-	Deduce(lineView) []Cell
+	Deduce(Line) []Cell
 }
 
-func splitByWhite(cells []Cell) [][]Cell {
-	var segs [][]Cell
-	var start int
-	for i, c := range cells {
-		if c == CellWhite {
-			if start < i {
-				segs = append(segs, cells[start:i])
+// 白で区切られた区間を、開始位置とともに順に返す。区間は cells を切り出したもので、割り当てをしない
+func segmentsByWhite(cells []Cell) iter.Seq2[int, []Cell] {
+	return func(yield func(int, []Cell) bool) {
+		var start int
+		for i, c := range cells {
+			if c == CellWhite {
+				if start < i && !yield(start, cells[start:i]) {
+					return
+				}
+				start = i + 1
 			}
-			start = i + 1
 		}
+		if start < len(cells) {
+			yield(start, cells[start:])
+		}
+	}
+}
+
+// 白で区切られた最初の区間。無ければ nil
+func firstSegment(cells []Cell) []Cell {
+	for _, seg := range segmentsByWhite(cells) {
+		return seg
+	}
+	return nil
+}
+
+// Rule の結果のセル。最初に値を変えるときに元のセルを複製するので、
+// 何も変えなかった Rule は割り当てをせずに nil を返せる
+type lazyCells struct {
+	src   []Cell
+	cells []Cell
+}
+
+func (l *lazyCells) set(i int, c Cell) {
+	if l.cells == nil {
+		if l.src[i] == c {
+			return
+		}
+		l.cells = slices.Clone(l.src)
+	}
+	l.cells[i] = c
+}
+
+// 値を変えていればその結果、変えていなければ元のセル
+func (l *lazyCells) view() []Cell {
+	if l.cells == nil {
+		return l.src
 	}
-	if start < len(cells) {
-		segs = append(segs, cells[start:])
+	return l.cells
+}
+
+// 行・列を端から見た位置で読み書きする。reversed なら右端を 0 とする。
+// 両端に同じ処理を、セルを反転して複製せずに適用するのに使う
+type lineEnd struct {
+	result   *lazyCells
+	reversed bool
+}
+
+func (e lineEnd) index(i int) int {
+	if e.reversed {
+		return len(e.result.src) - 1 - i
+	}
+	return i
+}
+
+func (e lineEnd) at(i int) Cell     { return e.result.view()[e.index(i)] }
+func (e lineEnd) set(i int, c Cell) { e.result.set(e.index(i), c) }
+
+// 端から見て白で区切られた最初の区間の開始位置と長さ
+func (e lineEnd) firstSegment() (start, length int) {
+	n := len(e.result.src)
+	for start < n && e.at(start) == CellWhite {
+		start++
+	}
+	for start+length < n && e.at(start+length) != CellWhite {
+		length++
 	}
-	return segs
+	return start, length
 }
 
 type ZeroHintRule struct{}
@@ -32,7 +99,7 @@ func (e ZeroHintRule) Name() string {
 	return "ZeroHintRule"
 }
 
-func (r ZeroHintRule) Deduce(line lineView) []Cell {
+func (r ZeroHintRule) Deduce(line Line) []Cell {
 	cells := slices.Clone(line.Cells)
 	if len(line.Hints) != 1 || line.Hints[0] != 0 {
 		return nil
@@ -50,23 +117,27 @@ func (r MinimumSpacingRule) Name() string {
 	return "MinimumSpacingRule"
 }
 
-func (r MinimumSpacingRule) Deduce(line lineView) []Cell {
-	cells := slices.Clone(line.Cells)
-
-	segs := splitByWhite(cells)
-	if len(segs) != 1 {
+func (r MinimumSpacingRule) Deduce(line Line) []Cell {
+	// 当てはまらない行・列では複製しない
+	count, length := 0, 0
+	for _, seg := range segmentsByWhite(line.Cells) {
+		count++
+		length = len(seg)
+	}
+	if count != 1 {
 		return nil
 	}
 
-	seg := segs[0]
 	var sum int
 	for _, h := range line.Hints {
 		sum += h
 	}
-	if sum+(len(line.Hints)-1) != len(seg) {
+	if sum+(len(line.Hints)-1) != length {
 		return nil
 	}
 
+	cells := slices.Clone(line.Cells)
+	seg := firstSegment(cells)
 	var last int
 	for i, hint := range line.Hints {
 		for range hint {
@@ -152,17 +223,15 @@ func (r OverlapFillRule) rightAlignedStarts(cells []Cell, hints []int) []int {
 	return starts
 }
 
-func (r OverlapFillRule) Deduce(line lineView) []Cell {
-	cells := slices.Clone(line.Cells)
-
-	leftStarts := r.leftAlignedStarts(cells, line.Hints)
-	rightStarts := r.rightAlignedStarts(cells, line.Hints)
+func (r OverlapFillRule) Deduce(line Line) []Cell {
+	leftStarts := r.leftAlignedStarts(line.Cells, line.Hints)
+	rightStarts := r.rightAlignedStarts(line.Cells, line.Hints)
 
 	if leftStarts == nil || rightStarts == nil {
 		return nil
 	}
 
-	changed := false
+	result := lazyCells{src: line.Cells}
 	for i, hint := range line.Hints {
 		left := leftStarts[i]
 		right := rightStarts[i]
@@ -171,16 +240,12 @@ func (r OverlapFillRule) Deduce(line lineView) []Cell {
 		overlapEnd := min(left+hint, right+hint)
 
 		for p := overlapStart; p < overlapEnd; p++ {
-			if cells[p] == CellUndetermined {
-				cells[p] = CellBlack
-				changed = true
+			if line.Cells[p] == CellUndetermined {
+				result.set(p, CellBlack)
 			}
 		}
 	}
-	if !changed {
-		return nil
-	}
-	return cells
+	return result.cells
 }
 
 // 端が未確定なら黒をヒント分拡張する
@@ -190,35 +255,29 @@ func (r OverlapExpansionRule) Name() string {
 	return "OverlapExpansionRule"
 }
 
-func (r OverlapExpansionRule) applyLeft(cells []Cell, hint int) (changed bool) {
-	seg := splitByWhite(cells)[0]
-	firstBlackIndex := slices.Index(seg, CellBlack)
-	if firstBlackIndex == -1 || firstBlackIndex >= hint {
-		return false
+func (r OverlapExpansionRule) apply(end lineEnd, hint int) {
+	start, length := end.firstSegment()
+	firstBlackIndex := -1
+	for i := range length {
+		if end.at(start+i) == CellBlack {
+			firstBlackIndex = i
+			break
+		}
+	}
+	if firstBlackIndex == -1 || firstBlackIndex >= hint || length < hint {
+		return
 	}
 
 	for i := firstBlackIndex + 1; i < hint; i++ {
-		seg[i] = CellBlack
-		changed = true
+		end.set(start+i, CellBlack)
 	}
-	return changed
 }
 
-func (r OverlapExpansionRule) Deduce(line lineView) []Cell {
-	cells := slices.Clone(line.Cells)
-
-	firstHint := line.Hints[0]
-	changed1 := r.applyLeft(cells, firstHint)
-
-	slices.Reverse(cells)
-	lastHint := line.Hints[len(line.Hints)-1]
-	changed2 := r.applyLeft(cells, lastHint)
-
-	if !changed1 && !changed2 {
-		return nil
-	}
-	slices.Reverse(cells)
-	return cells
+func (r OverlapExpansionRule) Deduce(line Line) []Cell {
+	result := lazyCells{src: line.Cells}
+	r.apply(lineEnd{&result, false}, line.Hints[0])
+	r.apply(lineEnd{&result, true}, line.Hints[len(line.Hints)-1])
+	return result.cells
 }
 
 // 端に黒が確定した場合、ヒントサイズ分伸ばせる
@@ -228,36 +287,24 @@ func (r EdgeExpansionRule) Name() string {
 	return "EdgeExpansionRule"
 }
 
-func (r EdgeExpansionRule) applyLeft(cells []Cell, hint int) (changed bool) {
-	seg := splitByWhite(cells)[0]
-	if seg[0] != CellBlack || len(seg) < hint {
-		return false
+func (r EdgeExpansionRule) apply(end lineEnd, hint int) {
+	start, length := end.firstSegment()
+	if length == 0 || end.at(start) != CellBlack || length < hint {
+		return
 	}
 	for i := range hint {
-		seg[i] = CellBlack
-		changed = true
+		end.set(start+i, CellBlack)
 	}
-	if len(seg) > hint {
-		seg[hint] = CellWhite
+	if length > hint {
+		end.set(start+hint, CellWhite)
 	}
-	return changed
 }
 
-func (r EdgeExpansionRule) Deduce(line lineView) []Cell {
-	cells := slices.Clone(line.Cells)
-
-	firstHint := line.Hints[0]
-	changed1 := r.applyLeft(cells, firstHint)
-
-	slices.Reverse(cells)
-	lastHint := line.Hints[len(line.Hints)-1]
-	changed2 := r.applyLeft(cells, lastHint)
-
-	if !changed1 && !changed2 {
-		return nil
-	}
-	slices.Reverse(cells)
-	return cells
+func (r EdgeExpansionRule) Deduce(line Line) []Cell {
+	result := lazyCells{src: line.Cells}
+	r.apply(lineEnd{&result, false}, line.Hints[0])
+	r.apply(lineEnd{&result, true}, line.Hints[len(line.Hints)-1])
+	return result.cells
 }
 
 // 既に黒が hint 長に達しているブロックの前後を白確定
@@ -312,8 +359,8 @@ func findBlocksN(cells []Cell, n int) []Block {
 	return blocks
 }
 
-func (r BlockSatisfiedRule) Deduce(line lineView) []Cell {
-	cells := slices.Clone(line.Cells)
+func (r BlockSatisfiedRule) Deduce(line Line) []Cell {
+	cells := line.Cells
 	hint := r.maxHint(line.Hints)
 	if hint == 0 {
 		return nil
@@ -324,24 +371,18 @@ func (r BlockSatisfiedRule) Deduce(line lineView) []Cell {
 		return nil
 	}
 
-	changed := false
+	result := lazyCells{src: cells}
 	for _, block := range blocks {
 		prevStart := block.start - 1
 		if prevStart >= 0 && cells[prevStart] == CellUndetermined {
-			cells[prevStart] = CellWhite
-			changed = true
+			result.set(prevStart, CellWhite)
 		}
 		afterEnd := block.start + block.length
 		if afterEnd < len(cells) && cells[afterEnd] == CellUndetermined {
-			cells[afterEnd] = CellWhite
-			changed = true
+			result.set(afterEnd, CellWhite)
 		}
 	}
-
-	if !changed {
-		return nil
-	}
-	return cells
+	return result.cells
 }
 
 // 最小 hint が収まらない区間を白確定
@@ -359,25 +400,18 @@ func (r PruneImpossibleSegmentRule) minHint(hints []int) int {
 	return hint
 }
 
-func (r PruneImpossibleSegmentRule) Deduce(line lineView) []Cell {
-	cells := slices.Clone(line.Cells)
-
+func (r PruneImpossibleSegmentRule) Deduce(line Line) []Cell {
 	hint := r.minHint(line.Hints)
-	changed := false
 
-	segs := splitByWhite(cells)
-	for i, seg := range segs {
+	result := lazyCells{src: line.Cells}
+	for offset, seg := range segmentsByWhite(line.Cells) {
 		if len(seg) < hint {
-			changed = true
 			for j := range seg {
-				segs[i][j] = CellWhite
+				result.set(offset+j, CellWhite)
 			}
 		}
 	}
-	if !changed {
-		return nil
-	}
-	return cells
+	return result.cells
 }
 
 // すべての hint を満たした後の残りは白
@@ -387,16 +421,14 @@ func (r FillRemainingWhiteRule) Name() string {
 	return "FillRemainingWhiteRule"
 }
 
-func (r FillRemainingWhiteRule) Deduce(line lineView) []Cell {
-	cells := slices.Clone(line.Cells)
-
+func (r FillRemainingWhiteRule) Deduce(line Line) []Cell {
 	sumHints := 0
 	for _, h := range line.Hints {
 		sumHints += h
 	}
 
 	blackCount := 0
-	for _, c := range cells {
+	for _, c := range line.Cells {
 		if c == CellBlack {
 			blackCount++
 		}
@@ -406,19 +438,134 @@ func (r FillRemainingWhiteRule) Deduce(line lineView) []Cell {
 		return nil
 	}
 
+	result := lazyCells{src: line.Cells}
+	for i, c := range line.Cells {
+		if c == CellUndetermined {
+			result.set(i, CellWhite)
+		}
+	}
+	return result.cells
+}
+
+// ヒントの全配置のうち現在のセルと矛盾しないものの共通部分を確定
+type ExhaustivePlacementRule struct{}
+
+func (r ExhaustivePlacementRule) Name() string {
+	return "ExhaustivePlacementRule"
+}
+
+func (r ExhaustivePlacementRule) Deduce(line Line) []Cell {
+	cells := slices.Clone(line.Cells)
+
+	possible := placementCandidates(cells, line.Hints, line.Colors)
+	if possible == nil {
+		return nil
+	}
+
 	changed := false
 	for i, c := range cells {
-		if c == CellUndetermined {
-			cells[i] = CellWhite
+		if c != CellUndetermined {
+			continue
+		}
+		if v, ok := possible[i].single(); ok {
+			cells[i] = v
 			changed = true
 		}
 	}
-
 	if !changed {
 		return nil
 	}
 	return cells
 }
 
-// 仮に黒／白を置き、矛盾が出たら逆を確定
+// 仮に黒／白を置き、矛盾が出たら逆を確定する。行・列のルールと違い盤面全体を見るので、
+// Solver に WithHypothesis を指定して使う。どの値も矛盾するセルがあれば *ContradictionError になる
 type HypothesisRule struct{}
+
+func (r HypothesisRule) Name() string {
+	return "HypothesisRule"
+}
+
+// 仮定した盤面を行・列のルールで進める。矛盾したら nil を返す
+func (r HypothesisRule) probe(ctx context.Context, s Solver, game *Game, row, col int, assumed Cell) grid {
+	probe := game.clone()
+	probe.board.set(row, col, assumed)
+	if _, err := s.propagate(ctx, probe); errors.Is(err, ErrContradiction) {
+		return nil
+	}
+	return probe.board
+}
+
+// start 番目のセルから盤面を1周し、仮定を試す。矛盾しなかった仮定のすべてで同じ値になったセルは、
+// 仮定したセル以外も含めて確定する。次に調べ始める位置として、最後に確定した位置の次を返す
+func (r HypothesisRule) sweep(ctx context.Context, s Solver, game *Game, start int) (deds []Deduction, next int, err error) {
+	rows, cols := game.board.GetRows(), game.board.GetColumns()
+	next = start
+	for k := range rows * cols {
+		pos := (start + k) % (rows * cols)
+		i, j := pos/cols, pos%cols
+		if game.board.at(i, j) != CellUndetermined {
+			continue
+		}
+		var survivors []grid
+		var assumptions []Cell
+		for _, assumed := range game.cellCandidates(i, j) {
+			if probe := r.probe(ctx, s, game, i, j, assumed); probe != nil {
+				survivors = append(survivors, probe)
+				assumptions = append(assumptions, assumed)
+			}
+		}
+		// 取り消された仮定は矛盾を見落としているので確定に使えない
+		if ctx.Err() != nil {
+			return deds, next, nil
+		}
+		if len(survivors) == 0 {
+			ref := LineRef{LineKindRow, i}
+			return deds, next, &ContradictionError{ref, slices.Clone(game.hintsOf(ref)), lineAccessor{game.board, ref}.Cells()}
+		}
+		if committed := r.commitAgreed(game, &Probe{i, j, assumptions}, survivors); len(committed) > 0 {
+			deds = append(deds, committed...)
+			next = (pos + 1) % (rows * cols)
+		}
+	}
+	return deds, next, nil
+}
+
+// 未確定のセルのうち、survivors のすべてで同じ値に決まったセルを確定し、変わった行ごとの推論を返す。
+// 推論にはそれを導いた probe を記録する
+func (r HypothesisRule) commitAgreed(game *Game, probe *Probe, survivors []grid) []Deduction {
+	var deds []Deduction
+	for i := range game.board.GetRows() {
+		ref := LineRef{LineKindRow, i}
+		acc := lineAccessor{game.board, ref}
+		before := acc.Cells()
+		after := slices.Clone(before)
+		for j, c := range before {
+			if c != CellUndetermined {
+				continue
+			}
+			v := survivors[0].at(i, j)
+			agreed := v != CellUndetermined
+			for _, survivor := range survivors[1:] {
+				agreed = agreed && survivor.at(i, j) == v
+			}
+			if agreed {
+				after[j] = v
+			}
+		}
+		if slices.Equal(before, after) {
+			continue
+		}
+		acc.Update(after)
+		deds = append(deds, Deduction{
+			ruleName: r.Name(),
+			hints:    slices.Clone(game.hintsOf(ref)),
+			colors:   slices.Clone(game.colorsOf(ref)),
+			lineRef:  ref,
+			before:   before,
+			after:    after,
+			probe:    probe,
+		})
+	}
+	return deds
+}
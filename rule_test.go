@@ -13,7 +13,7 @@ const (
 	B = CellBlack
 )
 
-func assertRuleIsPure(t *testing.T, r Rule, line lineView) {
+func assertRuleIsPure(t *testing.T, r Rule, line Line) {
 	t.Helper()
 
 	origCells := slices.Clone(line.Cells)
@@ -29,20 +29,119 @@ func assertRuleIsPure(t *testing.T, r Rule, line lineView) {
 	}
 }
 
-func TestSplitByWhite(t *testing.T) {
+func TestSegmentsByWhite(t *testing.T) {
 	tests := []struct {
 		cells    []Cell
+		starts   []int
 		expected [][]Cell
 	}{
-		{[]Cell{U, W, U}, [][]Cell{{U}, {U}}},
-		{[]Cell{W, B, U, U, W}, [][]Cell{{B, U, U}}},
+		{[]Cell{U, W, U}, []int{0, 2}, [][]Cell{{U}, {U}}},
+		{[]Cell{W, B, U, U, W}, []int{1}, [][]Cell{{B, U, U}}},
 	}
 
 	for i, tt := range tests {
 		t.Run(fmt.Sprintf("case%d", i), func(t *testing.T) {
-			got := splitByWhite(tt.cells)
+			var starts []int
+			var got [][]Cell
+			for start, seg := range segmentsByWhite(tt.cells) {
+				starts = append(starts, start)
+				got = append(got, seg)
+			}
+
+			if !reflect.DeepEqual(got, tt.expected) || !slices.Equal(starts, tt.starts) {
+				t.Errorf("expected %v %v, got %v %v", tt.starts, tt.expected, starts, got)
+			}
+		})
+	}
+}
+
+func TestLineIsSolvable(t *testing.T) {
+	tests := []struct {
+		cells    []Cell
+		hints    []int
+		expected bool
+	}{
+		{[]Cell{U, U, U}, []int{0}, true},
+		{[]Cell{U, B, U}, []int{0}, false},
+		{[]Cell{U, U, U}, []int{1, 1}, true},
+		{[]Cell{U, U}, []int{1, 1}, false},
+		{[]Cell{B, B, U}, []int{1}, false},
+		{[]Cell{U, W, U, U}, []int{2}, true},
+		{[]Cell{U, U, W, U}, []int{3}, false},
+		{[]Cell{B, W, B, W, B}, []int{1, 1}, false},
+		{[]Cell{B, W, U, U, B}, []int{1, 2}, true},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("case%d", i), func(t *testing.T) {
+			got := Line{Cells: tt.cells, Hints: tt.hints}.IsSolvable()
+
+			if got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFilledMatches(t *testing.T) {
+	R := ColorCell(1)
+	tests := []struct {
+		cells  []Cell
+		hints  []int
+		colors []Color
+	}{
+		{[]Cell{W, W}, []int{0}, nil},
+		{[]Cell{B, W, B}, []int{1, 1}, nil},
+		{[]Cell{B, B, W}, []int{1}, nil},
+		{[]Cell{B, W, B}, []int{1}, nil},
+		{[]Cell{W, W, W}, []int{1}, nil},
+		{[]Cell{B, R, W}, []int{1, 1}, []Color{0, 1}},
+		{[]Cell{R, B, W}, []int{1, 1}, []Color{0, 1}},
+		{[]Cell{R, R, W}, []int{1, 1}, []Color{1, 1}},
+	}
+
+	// 埋まった行・列では配置の DP と結果が一致する
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("case%d", i), func(t *testing.T) {
+			expected := Line{Cells: tt.cells, Hints: tt.hints, Colors: tt.colors}.IsSolvable()
+
+			if got := filledMatches(tt.cells, tt.hints, tt.colors); got != expected {
+				t.Errorf("expected %v, got %v", expected, got)
+			}
+		})
+	}
+}
 
-			if !reflect.DeepEqual(got, tt.expected) {
+func TestColorLine(t *testing.T) {
+	R := ColorCell(1)
+	tests := []struct {
+		cells    []Cell
+		hints    []int
+		colors   []Color
+		solvable bool
+		expected []Cell
+	}{
+		{[]Cell{U, U}, []int{1, 1}, []Color{0, 1}, true, []Cell{B, R}},
+		{[]Cell{U, U}, []int{1, 1}, []Color{0, 0}, false, nil},
+		{[]Cell{U, U, U}, []int{1, 1}, []Color{1, 1}, true, []Cell{R, W, R}},
+		{[]Cell{U, U, U}, []int{2, 1}, []Color{0, 1}, true, []Cell{B, B, R}},
+		{[]Cell{U, U, U, U}, []int{2, 1}, []Color{1, 0}, true, []Cell{U, R, U, U}},
+		{[]Cell{U, R, U, U}, []int{2, 1}, []Color{0, 1}, false, nil},
+		{[]Cell{U, U, R, U}, []int{2, 1}, []Color{0, 1}, true, []Cell{B, B, R, W}},
+		{[]Cell{U, U, B, U}, []int{1, 1}, []Color{1, 0}, true, []Cell{U, U, B, W}},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("case%d", i), func(t *testing.T) {
+			line := Line{Cells: tt.cells, Hints: tt.hints, Colors: tt.colors}
+			assertRuleIsPure(t, ExhaustivePlacementRule{}, line)
+
+			if got := line.IsSolvable(); got != tt.solvable {
+				t.Errorf("expected solvable %v, got %v", tt.solvable, got)
+			}
+			got := ExhaustivePlacementRule{}.Deduce(line)
+
+			if !reflect.DeepEqual(tt.expected, got) {
 				t.Errorf("expected %v, got %v", tt.expected, got)
 			}
 		})
@@ -91,11 +190,19 @@ func TestAllRule(t *testing.T) {
 		{FillRemainingWhiteRule{}, []Cell{U, B, U}, []int{1}, []Cell{W, B, W}},
 		{FillRemainingWhiteRule{}, []Cell{B, U, B, B}, []int{1, 2}, []Cell{B, W, B, B}},
 		{FillRemainingWhiteRule{}, []Cell{U, B, B, W, U, B}, []int{2, 1}, []Cell{W, B, B, W, W, B}},
+		{ExhaustivePlacementRule{}, []Cell{U, U, U}, []int{0}, []Cell{W, W, W}},
+		{ExhaustivePlacementRule{}, []Cell{U, U, U}, []int{2}, []Cell{U, B, U}},
+		{ExhaustivePlacementRule{}, []Cell{U, U, U, U, U}, []int{1, 1}, nil},
+		{ExhaustivePlacementRule{}, []Cell{U, U, U, U, U}, []int{1, 1, 1}, []Cell{B, W, B, W, B}},
+		{ExhaustivePlacementRule{}, []Cell{U, B, U, U, U, U}, []int{3}, []Cell{U, B, B, U, W, W}},
+		{ExhaustivePlacementRule{}, []Cell{U, U, B, U, U, W, U}, []int{3, 1}, nil},
+		{ExhaustivePlacementRule{}, []Cell{U, U, W, U, U, U, B, U}, []int{2, 3}, []Cell{B, B, W, W, U, B, B, U}},
+		{ExhaustivePlacementRule{}, []Cell{B, B, U}, []int{1}, nil},
 	}
 
 	for i, tt := range tests {
 		t.Run(fmt.Sprintf("%s-case%d", tt.rule.Name(), i), func(t *testing.T) {
-			line := lineView{tt.cells, tt.hints}
+			line := Line{Cells: tt.cells, Hints: tt.hints}
 			assertRuleIsPure(t, tt.rule, line)
 
 			got := tt.rule.Deduce(line)
@@ -0,0 +1,133 @@
+package picrosssolver
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrNoSolution = errors.New("解が存在しない")
+
+func firstUndetermined(g grid) (row, col int, ok bool) {
+	for i := range g.GetRows() {
+		for j := range g.GetColumns() {
+			if g.at(i, j) == CellUndetermined {
+				return i, j, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// 探索で分岐した回数
+type searchStats struct {
+	branches int
+}
+
+// 行・列のルールで進めた後、未確定セルで黒／白に分岐する深さ優先探索。
+// 解が見つかるたびに yield を呼び、false が返ったら探索を打ち切る。stats は nil でもよい。
+// ctx が取り消されたら探索を打ち切り ctx.Err() を返す
+func (s Solver) search(ctx context.Context, game *Game, yield func(Board) bool, stats *searchStats) (bool, error) {
+	if _, err := s.propagate(ctx, game); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return false, ctxErr
+		}
+		return true, nil
+	}
+	row, col, ok := firstUndetermined(game.board)
+	if !ok {
+		return yield(toBoard(game.board)), nil
+	}
+	if stats != nil {
+		stats.branches++
+	}
+	for _, c := range game.cellCandidates(row, col) {
+		branch := game.clone()
+		branch.board.set(row, col, c)
+		if more, err := s.search(ctx, branch, yield, stats); !more {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// 探索で解をひとつ求め、game の盤面にも反映する
+func (s Solver) Solve(game *Game) (Board, error) {
+	return s.SolveContext(context.Background(), game)
+}
+
+// Solve と同じだが、ctx が取り消されたら探索を打ち切り ctx.Err() を返す
+func (s Solver) SolveContext(ctx context.Context, game *Game) (Board, error) {
+	var solution Board
+	if _, err := s.search(ctx, game.clone(), func(b Board) bool {
+		solution = b
+		return false
+	}, nil); err != nil {
+		return nil, err
+	}
+	if solution == nil {
+		return nil, ErrNoSolution
+	}
+	for i := range solution {
+		game.board.setLine(LineRef{LineKindRow, i}, solution[i])
+	}
+	return solution.clone(), nil
+}
+
+// 解を最大 limit 個まで列挙する。limit が 0 以下なら全て列挙する
+func (s Solver) Enumerate(game *Game, limit int) []Board {
+	solutions, _ := s.EnumerateContext(context.Background(), game, limit)
+	return solutions
+}
+
+// Enumerate と同じだが、ctx が取り消されたらそれまでに見つけた解と ctx.Err() を返す
+func (s Solver) EnumerateContext(ctx context.Context, game *Game, limit int) ([]Board, error) {
+	var solutions []Board
+	_, err := s.search(ctx, game.clone(), func(b Board) bool {
+		solutions = append(solutions, b)
+		return limit <= 0 || len(solutions) < limit
+	}, nil)
+	return solutions, err
+}
+
+type Uniqueness uint8
+
+const (
+	UniquenessNone Uniqueness = iota
+	UniquenessUnique
+	UniquenessMultiple
+)
+
+func (u Uniqueness) String() string {
+	switch u {
+	case UniquenessNone:
+		return "none"
+	case UniquenessUnique:
+		return "unique"
+	case UniquenessMultiple:
+		return "multiple"
+	default:
+		panic("invalid uniqueness")
+	}
+}
+
+// 解が一意かを判定する。複数ある場合は異なる2つの盤面を返す
+func (s Solver) CheckUniqueness(game *Game) (Uniqueness, []Board) {
+	u, solutions, _ := s.CheckUniquenessContext(context.Background(), game)
+	return u, solutions
+}
+
+// CheckUniqueness と同じだが、ctx が取り消されたら判定を打ち切り ctx.Err() を返す
+func (s Solver) CheckUniquenessContext(ctx context.Context, game *Game) (Uniqueness, []Board, error) {
+	solutions, err := s.EnumerateContext(ctx, game, 2)
+	if err != nil {
+		return UniquenessNone, nil, err
+	}
+	switch len(solutions) {
+	case 0:
+		return UniquenessNone, nil, nil
+	case 1:
+		return UniquenessUnique, solutions, nil
+	default:
+		return UniquenessMultiple, solutions, nil
+	}
+}
@@ -0,0 +1,164 @@
+// server は solver を HTTP の JSON API として公開する
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	picrosssolver "github.com/inahym196/picross-solver"
+)
+
+const (
+	defaultMaxBodyBytes = 1 << 20
+	defaultTimeout      = 10 * time.Second
+)
+
+type Options struct {
+	// リクエスト本文の上限のバイト数。0 なら 1MiB
+	MaxBodyBytes int64
+	// 1リクエストの処理時間の上限。0 なら 10秒
+	Timeout time.Duration
+	// nil なら NewSolver() の既定のルールで解く
+	Solver *picrosssolver.Solver
+}
+
+type server struct {
+	solver       picrosssolver.Solver
+	maxBodyBytes int64
+	timeout      time.Duration
+}
+
+const timeoutMessage = `{"error":"処理時間の上限を超えた"}`
+
+// POST /solve、POST /solve/stream と POST /validate を受け付ける http.Handler を作る
+func New(opts Options) http.Handler {
+	s := &server{solver: picrosssolver.NewSolver(), maxBodyBytes: opts.MaxBodyBytes, timeout: opts.Timeout}
+	if opts.Solver != nil {
+		s.solver = *opts.Solver
+	}
+	if s.maxBodyBytes <= 0 {
+		s.maxBodyBytes = defaultMaxBodyBytes
+	}
+	if s.timeout <= 0 {
+		s.timeout = defaultTimeout
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("POST /solve", http.TimeoutHandler(http.HandlerFunc(s.handleSolve), s.timeout, timeoutMessage))
+	mux.Handle("POST /validate", http.TimeoutHandler(http.HandlerFunc(s.handleValidate), s.timeout, timeoutMessage))
+	// http.TimeoutHandler は Flush できないので、ストリームは自分で時間を見る
+	mux.HandleFunc("POST /solve/stream", s.handleStream)
+	return mux
+}
+
+type puzzleRequest struct {
+	RowHints [][]int `json:"rowHints"`
+	ColHints [][]int `json:"colHints"`
+}
+
+type deductionResponse struct {
+	Rule   string `json:"rule"`
+	Line   string `json:"line"`
+	Hints  []int  `json:"hints"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+type solveResponse struct {
+	Board      []string            `json:"board"`
+	Solved     bool                `json:"solved"`
+	Iterations int                 `json:"iterations"`
+	Trace      []deductionResponse `json:"trace"`
+	Error      string              `json:"error,omitempty"`
+}
+
+type validateResponse struct {
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// 行・列のセルを Board.Print と同じ記号で表す
+func cellsString(cells []picrosssolver.Cell) string {
+	return picrosssolver.Board{cells}.Print()[0]
+}
+
+func newDeductionResponse(ded picrosssolver.Deduction) deductionResponse {
+	return deductionResponse{
+		Rule:   ded.Rule(),
+		Line:   ded.Line().String(),
+		Hints:  ded.Hints(),
+		Before: cellsString(ded.Before()),
+		After:  cellsString(ded.After()),
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// 本文を読んで req に入れる。失敗したらエラーを書き込んで false を返す
+func (s *server) decode(w http.ResponseWriter, r *http.Request, req *puzzleRequest) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.maxBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(req); err != nil {
+		status := http.StatusBadRequest
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		writeJSON(w, status, errorResponse{err.Error()})
+		return false
+	}
+	return true
+}
+
+func (s *server) handleSolve(w http.ResponseWriter, r *http.Request) {
+	var req puzzleRequest
+	if !s.decode(w, r, &req) {
+		return
+	}
+	game, err := picrosssolver.NewGame(req.RowHints, req.ColHints)
+	if err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, errorResponse{err.Error()})
+		return
+	}
+
+	// http.TimeoutHandler が時間切れで r.Context() を取り消すと、解くのも止まる
+	n, deds, err := s.solver.ApplyManyContext(r.Context(), game)
+	resp := solveResponse{
+		Board:      game.PrintBoard(),
+		Solved:     game.IsSolved(),
+		Iterations: n,
+		Trace:      make([]deductionResponse, len(deds)),
+	}
+	for i, ded := range deds {
+		resp.Trace[i] = newDeductionResponse(ded)
+	}
+	status := http.StatusOK
+	if err != nil {
+		// 矛盾したヒントでも途中までの盤面と推論は返す
+		resp.Error = err.Error()
+		status = http.StatusUnprocessableEntity
+	}
+	writeJSON(w, status, resp)
+}
+
+func (s *server) handleValidate(w http.ResponseWriter, r *http.Request) {
+	var req puzzleRequest
+	if !s.decode(w, r, &req) {
+		return
+	}
+	if _, err := picrosssolver.NewGame(req.RowHints, req.ColHints); err != nil {
+		writeJSON(w, http.StatusOK, validateResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, validateResponse{Valid: true})
+}
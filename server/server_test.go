@@ -0,0 +1,183 @@
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	picrosssolver "github.com/inahym196/picross-solver"
+	"github.com/inahym196/picross-solver/server"
+)
+
+func post(t *testing.T, h http.Handler, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+type solveResponse struct {
+	Board      []string `json:"board"`
+	Solved     bool     `json:"solved"`
+	Iterations int      `json:"iterations"`
+	Trace      []struct {
+		Rule   string `json:"rule"`
+		Line   string `json:"line"`
+		Hints  []int  `json:"hints"`
+		Before string `json:"before"`
+		After  string `json:"after"`
+	} `json:"trace"`
+	Error string `json:"error"`
+}
+
+func TestSolve(t *testing.T) {
+	tests := []struct {
+		body     string
+		status   int
+		board    []string
+		solved   bool
+		traceLen int
+	}{
+		{`{"rowHints":[[0],[2]],"colHints":[[1],[1]]}`, http.StatusOK, []string{"__", "##"}, true, 2},
+		{`{"rowHints":[[1],[1]],"colHints":[[1],[1]]}`, http.StatusOK, []string{"??", "??"}, false, 0},
+		{`{"rowHints":[[2],[2]],"colHints":[[2],[0],[2]]}`, http.StatusUnprocessableEntity, []string{"##?", "##?"}, false, 3},
+		{`{"rowHints":[[3]],"colHints":[[1],[1]]}`, http.StatusUnprocessableEntity, nil, false, 0},
+		{`{"rowHints":[[1]]`, http.StatusBadRequest, nil, false, 0},
+		{`{"rows":[[1]]}`, http.StatusBadRequest, nil, false, 0},
+	}
+	h := server.New(server.Options{})
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("case%d", i), func(t *testing.T) {
+			rec := post(t, h, "/solve", tt.body)
+
+			if rec.Code != tt.status {
+				t.Fatalf("expected status %v, got %v (%s)", tt.status, rec.Code, rec.Body)
+			}
+			var resp solveResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if rec.Code != http.StatusOK && resp.Error == "" {
+				t.Errorf("expected error message")
+			}
+			if !reflect.DeepEqual(resp.Board, tt.board) {
+				t.Errorf("expected %v, got %v", tt.board, resp.Board)
+			}
+			if resp.Solved != tt.solved {
+				t.Errorf("expected solved %v, got %v", tt.solved, resp.Solved)
+			}
+			if len(resp.Trace) != tt.traceLen {
+				t.Errorf("expected %v deductions, got %v", tt.traceLen, len(resp.Trace))
+			}
+		})
+	}
+}
+
+func TestSolveTrace(t *testing.T) {
+	h := server.New(server.Options{})
+
+	rec := post(t, h, "/solve", `{"rowHints":[[0],[2]],"colHints":[[1],[1]]}`)
+
+	var resp solveResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Iterations != 1 {
+		t.Errorf("expected 1, got %v", resp.Iterations)
+	}
+	got := resp.Trace[1]
+	if got.Rule != "MinimumSpacingRule" || got.Line != "Row[1]" || got.Before != "??" || got.After != "##" || !reflect.DeepEqual(got.Hints, []int{2}) {
+		t.Errorf("unexpected deduction %+v", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		body   string
+		status int
+		valid  bool
+	}{
+		{`{"rowHints":[[1],[1]],"colHints":[[1],[1]]}`, http.StatusOK, true},
+		{`{"rowHints":[[1],[1]],"colHints":[[2],[1]]}`, http.StatusOK, false},
+		{`{"rowHints":[],"colHints":[[1]]}`, http.StatusOK, false},
+		{`not json`, http.StatusBadRequest, false},
+	}
+	h := server.New(server.Options{})
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("case%d", i), func(t *testing.T) {
+			rec := post(t, h, "/validate", tt.body)
+
+			if rec.Code != tt.status {
+				t.Fatalf("expected status %v, got %v (%s)", tt.status, rec.Code, rec.Body)
+			}
+			var resp struct {
+				Valid bool   `json:"valid"`
+				Error string `json:"error"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.Valid != tt.valid {
+				t.Errorf("expected %v, got %v", tt.valid, resp.Valid)
+			}
+			if !resp.Valid && resp.Error == "" {
+				t.Errorf("expected error message")
+			}
+		})
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	h := server.New(server.Options{})
+	rec := httptest.NewRecorder()
+
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/solve", nil))
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected %v, got %v", http.StatusMethodNotAllowed, rec.Code)
+	}
+}
+
+func TestMaxBodyBytes(t *testing.T) {
+	h := server.New(server.Options{MaxBodyBytes: 16})
+
+	rec := post(t, h, "/solve", `{"rowHints":[[0],[2]],"colHints":[[1],[1]]}`)
+
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected %v, got %v", http.StatusRequestEntityTooLarge, rec.Code)
+	}
+}
+
+// 推論のたびに待つルール
+type slowRule struct{}
+
+func (slowRule) Name() string { return "slowRule" }
+
+func (slowRule) Deduce(picrosssolver.Line) []picrosssolver.Cell {
+	time.Sleep(50 * time.Millisecond)
+	return nil
+}
+
+func TestTimeout(t *testing.T) {
+	solver := picrosssolver.NewSolver(picrosssolver.WithRules(slowRule{}))
+	srv := httptest.NewServer(server.New(server.Options{Timeout: 10 * time.Millisecond, Solver: &solver}))
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/solve", "application/json", strings.NewReader(`{"rowHints":[[1]],"colHints":[[1]]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected %v, got %v", http.StatusServiceUnavailable, resp.StatusCode)
+	}
+}
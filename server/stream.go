@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	picrosssolver "github.com/inahym196/picross-solver"
+)
+
+type passEvent struct {
+	Pass       int      `json:"pass"`
+	Board      []string `json:"board"`
+	Deductions int      `json:"deductions"`
+}
+
+type doneEvent struct {
+	Board  []string `json:"board"`
+	Solved bool     `json:"solved"`
+	Passes int      `json:"passes"`
+}
+
+// Server-Sent Events の1件を書いて送り出す
+func writeEvent(w http.ResponseWriter, event string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+		return err
+	}
+	return http.NewResponseController(w).Flush()
+}
+
+// ApplyManyContext の進行を SSE で送る Observer。
+// 送れなくなったら cancel で解くのを止める
+type streamObserver struct {
+	picrosssolver.NopObserver
+	w      http.ResponseWriter
+	game   *picrosssolver.Game
+	cancel context.CancelFunc
+	// パスで反映した推論の数
+	deductions int
+	// 盤面が変わったパスの pass イベント。パスを終えたと分かってから送る
+	pending *passEvent
+	err     error
+}
+
+func (o *streamObserver) write(event string, v any) {
+	if o.err != nil {
+		return
+	}
+	if o.err = writeEvent(o.w, event, v); o.err != nil {
+		o.cancel()
+	}
+}
+
+func (o *streamObserver) flush() {
+	if o.pending != nil {
+		o.write("pass", *o.pending)
+		o.pending = nil
+	}
+}
+
+func (o *streamObserver) OnPassStart(int) {
+	o.flush()
+	o.deductions = 0
+}
+
+func (o *streamObserver) OnDeduction(ded picrosssolver.Deduction) {
+	o.deductions++
+	o.write("deduction", newDeductionResponse(ded))
+}
+
+// 矛盾や取り消しで途中で終わったパスも呼ばれるので、ここではまだ送らない
+func (o *streamObserver) OnPassEnd(n int, changed bool) {
+	if changed {
+		o.pending = &passEvent{n, o.game.PrintBoard(), o.deductions}
+	}
+}
+
+// 推論を1件ずつ deduction イベントで、各パスの後の盤面を pass イベントで送る。
+// 解けるか進めなくなったら done、矛盾や時間切れなら error で終える
+func (s *server) handleStream(w http.ResponseWriter, r *http.Request) {
+	var req puzzleRequest
+	if !s.decode(w, r, &req) {
+		return
+	}
+	game, err := picrosssolver.NewGame(req.RowHints, req.ColHints)
+	if err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, errorResponse{err.Error()})
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.timeout)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	observer := &streamObserver{w: w, game: game, cancel: cancel}
+	solver := s.solver
+	picrosssolver.WithObserver(observer)(&solver)
+	n, _, err := solver.ApplyManyContext(ctx, game)
+	// 上限はパスの間で確かめるので、最後のパスは終わっている
+	if err == nil || errors.Is(err, picrosssolver.ErrIterationLimit) || errors.Is(err, picrosssolver.ErrDeductionLimit) {
+		observer.flush()
+	}
+	switch {
+	case observer.err != nil:
+	case err != nil:
+		observer.write("error", errorResponse{err.Error()})
+	default:
+		observer.write("done", doneEvent{game.PrintBoard(), game.IsSolved(), n})
+	}
+}
@@ -0,0 +1,164 @@
+package server_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	picrosssolver "github.com/inahym196/picross-solver"
+	"github.com/inahym196/picross-solver/server"
+)
+
+type event struct {
+	name string
+	data string
+}
+
+// SSE の本文を event と data の組に分ける
+func readEvents(t *testing.T, resp *http.Response) []event {
+	t.Helper()
+	var events []event
+	var current event
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			current.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			current.data = strings.TrimPrefix(line, "data: ")
+		case line == "":
+			events = append(events, current)
+			current = event{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return events
+}
+
+func TestStream(t *testing.T) {
+	tests := []struct {
+		body     string
+		expected []string
+		last     string
+	}{
+		{`{"rowHints":[[0],[2]],"colHints":[[1],[1]]}`, []string{"deduction", "deduction", "pass", "done"}, `{"board":["__","##"],"solved":true,"passes":1}`},
+		{`{"rowHints":[[1],[1]],"colHints":[[1],[1]]}`, []string{"done"}, `{"board":["??","??"],"solved":false,"passes":0}`},
+		{`{"rowHints":[[2],[2]],"colHints":[[2],[0],[2]]}`, []string{"deduction", "deduction", "deduction", "error"}, ""},
+	}
+	srv := httptest.NewServer(server.New(server.Options{}))
+	defer srv.Close()
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("case%d", i), func(t *testing.T) {
+			resp, err := http.Post(srv.URL+"/solve/stream", "application/json", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+				t.Fatalf("expected text/event-stream, got %v", got)
+			}
+
+			events := readEvents(t, resp)
+
+			var names []string
+			for _, e := range events {
+				names = append(names, e.name)
+			}
+			if !reflect.DeepEqual(names, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, names)
+			}
+			if last := events[len(events)-1]; tt.last != "" && last.data != tt.last {
+				t.Errorf("expected %v, got %v", tt.last, last.data)
+			}
+		})
+	}
+}
+
+func TestStreamEvents(t *testing.T) {
+	srv := httptest.NewServer(server.New(server.Options{}))
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/solve/stream", "application/json", strings.NewReader(`{"rowHints":[[0],[2]],"colHints":[[1],[1]]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	events := readEvents(t, resp)
+
+	var ded struct {
+		Rule   string `json:"rule"`
+		Line   string `json:"line"`
+		Before string `json:"before"`
+		After  string `json:"after"`
+	}
+	if err := json.Unmarshal([]byte(events[0].data), &ded); err != nil {
+		t.Fatal(err)
+	}
+	if ded.Rule != "ZeroHintRule" || ded.Line != "Row[0]" || ded.Before != "??" || ded.After != "__" {
+		t.Errorf("unexpected deduction %+v", ded)
+	}
+	var pass struct {
+		Pass       int      `json:"pass"`
+		Board      []string `json:"board"`
+		Deductions int      `json:"deductions"`
+	}
+	if err := json.Unmarshal([]byte(events[2].data), &pass); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"__", "##"}
+	if pass.Pass != 0 || pass.Deductions != 2 || !reflect.DeepEqual(pass.Board, expected) {
+		t.Errorf("unexpected pass %+v", pass)
+	}
+}
+
+func TestStreamTimeout(t *testing.T) {
+	solver := picrosssolver.NewSolver(picrosssolver.WithRules(slowRule{}))
+	srv := httptest.NewServer(server.New(server.Options{Timeout: 10 * time.Millisecond, Solver: &solver}))
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/solve/stream", "application/json", strings.NewReader(`{"rowHints":[[1]],"colHints":[[1]]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	events := readEvents(t, resp)
+
+	if last := events[len(events)-1]; last.name != "error" {
+		t.Errorf("expected error event, got %v", last.name)
+	}
+}
+
+func TestStreamLimit(t *testing.T) {
+	solver := picrosssolver.NewSolver(picrosssolver.WithMaxIterations(1))
+	srv := httptest.NewServer(server.New(server.Options{Solver: &solver}))
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/solve/stream", "application/json", strings.NewReader(`{"rowHints":[[1],[2],[2,1],[2,1],[1,1]],"colHints":[[1,1],[3],[2],[1,2],[1]]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	events := readEvents(t, resp)
+
+	// 1パス目を終えてから上限で止まる
+	var names []string
+	for _, e := range events[len(events)-2:] {
+		names = append(names, e.name)
+	}
+	if expected := []string{"pass", "error"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+	if expected := fmt.Sprintf(`{"error":%q}`, picrosssolver.ErrIterationLimit.Error()); events[len(events)-1].data != expected {
+		t.Errorf("expected %v, got %v", expected, events[len(events)-1].data)
+	}
+}
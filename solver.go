@@ -1,57 +1,322 @@
 package picrosssolver
 
 import (
+	"context"
 	"slices"
 )
 
 type Solver struct {
-	deducer deducer
+	deducer    deducer
+	hypothesis HypothesisRule
+	// 行・列のルールで進めなくなったら仮定を試すか
+	probing  bool
+	cache    *lineCache
+	observer Observer
+	// 2 以上なら1パスの行・列を並行に評価する
+	workers int
+	// 0 なら無制限
+	maxIterations int
+	maxDeductions int
 }
 
-func NewSolver() Solver {
-	return Solver{newDeducer()}
+type Option func(*Solver)
+
+// 同じヒントとセルの行・列の推論結果を最大 size 件まで覚えておく。
+// キャッシュは Solver の複製の間で共有される
+func WithLineCache(size int) Option {
+	return func(s *Solver) {
+		if size > 0 {
+			s.cache = newLineCache(size)
+		}
+	}
 }
 
-func (s Solver) ApplyOnce(game *Game) (deds []deduction) {
+// 行・列のルールで進めなくなったら、未確定セルに値を仮定して矛盾を調べる HypothesisRule を使う。
+// 解ける盤面は増えるが、仮定のたびに盤面を複製して進めるので大きな盤面では遅い
+func WithHypothesis() Option {
+	return func(s *Solver) {
+		s.probing = true
+	}
+}
 
-	for i := range game.rowHints {
-		ref := lineRef{lineKindRow, i}
-		acc := lineAccessor{&game.board, ref}
-		line := lineView{
-			Cells: acc.Cells(),
-			Hints: slices.Clone(game.rowHints[i]),
+// ApplyMany の反復が n 回に達しても解けていなければ ErrIterationLimit で止める。0 以下なら無制限
+func WithMaxIterations(n int) Option {
+	return func(s *Solver) {
+		s.maxIterations = max(n, 0)
+	}
+}
+
+// ApplyMany の推論が n 件に達しても解けていなければ、そのパスの後で ErrDeductionLimit で止める。0 以下なら無制限
+func WithMaxDeductions(n int) Option {
+	return func(s *Solver) {
+		s.maxDeductions = max(n, 0)
+	}
+}
+
+// 行・列に適用するルールを rules で置き換える。WithoutRule と WithRuleOrder も含め、
+// ルールの指定は黒だけの行・列に効く。多色の行・列は常に ExhaustivePlacementRule だけで推論する
+func WithRules(rules ...Rule) Option {
+	return func(s *Solver) {
+		s.deducer.rules = slices.Clone(rules)
+	}
+}
+
+// 名前が name のルールを外す。該当するルールが無ければ何もしない
+func WithoutRule(name string) Option {
+	return func(s *Solver) {
+		i := slices.IndexFunc(s.deducer.rules, func(r Rule) bool { return r.Name() == name })
+		if i == -1 {
+			return
 		}
+		s.deducer.rules = slices.Delete(slices.Clone(s.deducer.rules), i, i+1)
+	}
+}
 
-		if lineDeds := s.deducer.DeduceLine(line, ref); len(lineDeds) > 0 {
-			last := lineDeds[len(lineDeds)-1]
-			acc.Update(last.after)
-			deds = append(deds, lineDeds...)
+// names の順にルールを並べ替える。names に無いルールは元の順で後ろに続く。
+// 該当するルールが無い名前は無視する
+func WithRuleOrder(names ...string) Option {
+	return func(s *Solver) {
+		rest := slices.Clone(s.deducer.rules)
+		ordered := make([]Rule, 0, len(rest))
+		for _, name := range names {
+			i := slices.IndexFunc(rest, func(r Rule) bool { return r.Name() == name })
+			if i == -1 {
+				continue
+			}
+			ordered = append(ordered, rest[i])
+			rest = slices.Delete(rest, i, i+1)
 		}
+		s.deducer.rules = append(ordered, rest...)
+	}
+}
+
+// 現在のルール名を適用順に返す
+func (s Solver) RuleNames() []string {
+	names := make([]string, len(s.deducer.rules))
+	for i, rule := range s.deducer.rules {
+		names[i] = rule.Name()
+	}
+	return names
+}
+
+func NewSolver(opts ...Option) Solver {
+	s := Solver{deducer: newDeducer(), hypothesis: HypothesisRule{}}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return s
+}
+
+// 確定済みのセルを書き換えていないか
+func overwritesDetermined(before, after []Cell) bool {
+	for i, c := range before {
+		if c != CellUndetermined && after[i] != c {
+			return true
+		}
+	}
+	return false
+}
+
+// 1本の行・列の評価結果。盤面にはまだ反映していない
+type lineResult struct {
+	line Line
+	deds []Deduction
+	err  error
+}
+
+// 行・列を buf に読んでルールを適用する。盤面は読むだけなので、同じ向きの行・列なら並行に呼べる。
+// 結果の line.Cells は buf を使うので、次に buf を使う前に commitLine すること。
+// ここでは確定済みのセルの書き換えと、埋まったのにヒントと合わない行・列だけを矛盾とする。
+// 未確定のセルが残る行・列の配置の有無は、推論が尽きたときに checkSolvable で調べる
+func (s Solver) evaluateLine(game *Game, ref LineRef, buf []Cell) lineResult {
+	// ヒントは Game が書き換えないので複製せずに渡す
+	line := Line{
+		Cells:  lineAccessor{game.board, ref}.CellsInto(buf),
+		Hints:  game.hintsOf(ref),
+		Colors: game.colorsOf(ref),
+	}
+	lineDeds := s.deduceLine(line, ref)
+	if len(lineDeds) == 0 && line.IsFilled() && !filledMatches(line.Cells, line.Hints, line.Colors) ||
+		len(lineDeds) > 0 && overwritesDetermined(line.Cells, lineDeds[len(lineDeds)-1].after) {
+		return lineResult{line: line, err: &ContradictionError{ref, slices.Clone(line.Hints), slices.Clone(line.Cells)}}
+	}
+	return lineResult{line: line, deds: lineDeds}
+}
+
+// 評価結果を盤面に反映し、Observer に通知する
+func (s Solver) commitLine(game *Game, ref LineRef, result lineResult) ([]Deduction, error) {
+	if s.observer != nil {
+		s.observer.OnLineEvaluated(ref, result.line.Hints, result.line.Cells)
+	}
+	if result.err != nil || len(result.deds) == 0 {
+		return nil, result.err
+	}
+	lineAccessor{game.board, ref}.Update(result.deds[len(result.deds)-1].after)
+	if s.observer != nil {
+		for _, ded := range result.deds {
+			s.observer.OnDeduction(ded)
+		}
+	}
+	return result.deds, nil
+}
+
+// 行・列を評価してすぐ反映する。buf は行・列を読む作業領域
+func (s Solver) applyLine(game *Game, ref LineRef, buf []Cell) ([]Deduction, error) {
+	return s.commitLine(game, ref, s.evaluateLine(game, ref, buf))
+}
+
+// 行・列を読む作業領域
+func newLineBuffer(game *Game) []Cell {
+	return make([]Cell, 0, max(len(game.rowHints), len(game.colHints)))
+}
+
+// 全ての行・列を1回ずつ評価する。Observer に渡すパスの番号は、
+// 同じ game でそれまでに ApplyOnce や ApplyMany が進めたパスの数になる
+func (s Solver) ApplyOnce(game *Game) ([]Deduction, error) {
+	return s.ApplyOnceContext(context.Background(), game)
+}
+
+// ApplyOnce と同じだが、行・列を評価する前に毎回 ctx を確かめ、
+// 取り消されていればそれまでの推論と ctx.Err() を返す
+func (s Solver) ApplyOnceContext(ctx context.Context, game *Game) ([]Deduction, error) {
+	n := s.startPass(game)
+	deds, err := s.applyOnce(ctx, game)
+	s.notifyPassEnd(n, len(deds) > 0)
+	return deds, err
+}
+
+// 全ての行・列を1回ずつ評価する。何も推論できなければ、配置の無い行・列が残っていないかも調べる
+func (s Solver) applyOnce(ctx context.Context, game *Game) (deds []Deduction, err error) {
+	if s.workers > 1 {
+		deds, err = s.applyOnceParallel(ctx, game)
+	} else {
+		deds, err = s.applyOnceSerial(ctx, game)
+	}
+	if err == nil && len(deds) == 0 {
+		err = s.checkSolvable(game)
+	}
+	return deds, err
+}
+
+// 行・列のルールは配置が無いことを見落として止まることがあるので、推論が尽きた盤面の
+// すべての行・列に配置が残っているかを調べる。DP が重いので、パスごとではなくここでだけ使う
+func (s Solver) checkSolvable(game *Game) error {
+	buf := newLineBuffer(game)
+	for _, kind := range []LineKind{LineKindRow, LineKindColumn} {
+		count := len(game.rowHints)
+		if kind == LineKindColumn {
+			count = len(game.colHints)
+		}
+		for i := range count {
+			ref := LineRef{kind, i}
+			line := Line{Cells: lineAccessor{game.board, ref}.CellsInto(buf), Hints: game.hintsOf(ref), Colors: game.colorsOf(ref)}
+			if !line.IsSolvable() {
+				return &ContradictionError{ref, slices.Clone(line.Hints), slices.Clone(line.Cells)}
+			}
+		}
+	}
+	return nil
+}
+
+func (s Solver) applyOnceSerial(ctx context.Context, game *Game) (deds []Deduction, err error) {
+	buf := newLineBuffer(game)
+	for i := range game.rowHints {
+		if err := ctx.Err(); err != nil {
+			return deds, err
+		}
+		lineDeds, err := s.applyLine(game, LineRef{LineKindRow, i}, buf)
+		if err != nil {
+			return deds, err
+		}
+		deds = append(deds, lineDeds...)
 	}
 	for i := range game.colHints {
-		ref := lineRef{lineKindColumn, i}
-		acc := lineAccessor{&game.board, ref}
-		line := lineView{
-			Cells: acc.Cells(),
-			Hints: slices.Clone(game.colHints[i]),
+		if err := ctx.Err(); err != nil {
+			return deds, err
+		}
+		lineDeds, err := s.applyLine(game, LineRef{LineKindColumn, i}, buf)
+		if err != nil {
+			return deds, err
 		}
+		deds = append(deds, lineDeds...)
+	}
+	return deds, nil
+}
 
-		if lineDeds := s.deducer.DeduceLine(line, ref); len(lineDeds) > 0 {
-			last := lineDeds[len(lineDeds)-1]
-			acc.Update(last.after)
-			deds = append(deds, lineDeds...)
+// 行・列のルールだけで進めなくなるまで繰り返す。仮定の検証にも使うので進行は通知しない
+func (s Solver) propagate(ctx context.Context, game *Game) (deds []Deduction, err error) {
+	s.observer = nil
+	for {
+		onceDeds, err := s.applyOnce(ctx, game)
+		deds = append(deds, onceDeds...)
+		if err != nil || len(onceDeds) == 0 {
+			return deds, err
 		}
 	}
-	return deds
 }
 
-func (s Solver) ApplyMany(game *Game) (int, []deduction) {
-	var deds []deduction
+func (s Solver) ApplyMany(game *Game) (int, []Deduction, error) {
+	return s.ApplyManyContext(context.Background(), game)
+}
+
+// ApplyMany と同じだが、WithHypothesis を指定していても仮定は試さず、行・列のルールだけで進める
+func (s Solver) ApplyManyLines(game *Game) (int, []Deduction, error) {
+	s.probing = false
+	return s.ApplyMany(game)
+}
+
+// ApplyMany と同じだが、パスの間と行・列の評価の間で ctx を確かめ、
+// 取り消されていればそれまでの推論と ctx.Err() を返す
+func (s Solver) ApplyManyContext(ctx context.Context, game *Game) (int, []Deduction, error) {
+	var deds []Deduction
+	// 仮定を次に調べ始めるセル
+	cursor := 0
 	for n := 0; ; n++ {
-		if OnceDeds := s.ApplyOnce(game); len(OnceDeds) > 0 {
-			deds = append(deds, OnceDeds...)
-			continue
+		if err := ctx.Err(); err != nil {
+			return n, deds, err
+		}
+		if err := s.checkLimits(game, n, len(deds)); err != nil {
+			return n, deds, err
+		}
+		pass := s.startPass(game)
+		passDeds, err := s.applyOnce(ctx, game)
+		if err == nil && len(passDeds) == 0 {
+			passDeds, err = s.hypothesize(ctx, game, &cursor)
 		}
-		return n, deds
+		s.notifyPassEnd(pass, len(passDeds) > 0)
+		deds = append(deds, passDeds...)
+		if err != nil || len(passDeds) == 0 {
+			return n, deds, err
+		}
+	}
+}
+
+// 行・列のルールで進めなくなった盤面に、WithHypothesis なら cursor のセルから仮定を試す。
+// 取り消されたら ctx.Err() を返す
+func (s Solver) hypothesize(ctx context.Context, game *Game, cursor *int) ([]Deduction, error) {
+	if !s.probing {
+		return nil, nil
+	}
+	deds, next, err := s.hypothesis.sweep(ctx, s, game, *cursor)
+	*cursor = next
+	if err != nil {
+		return nil, err
+	}
+	if s.observer != nil {
+		for _, ded := range deds {
+			s.observer.OnDeduction(ded)
+		}
+	}
+	return deds, ctx.Err()
+}
+
+// 反復回数か推論の件数が上限に達し、まだ解けていなければエラーを返す
+func (s Solver) checkLimits(game *Game, iterations, deductions int) error {
+	switch {
+	case s.maxIterations > 0 && iterations >= s.maxIterations && !game.IsSolved():
+		return ErrIterationLimit
+	case s.maxDeductions > 0 && deductions >= s.maxDeductions && !game.IsSolved():
+		return ErrDeductionLimit
 	}
+	return nil
 }
@@ -1,8 +1,11 @@
 package picrosssolver_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"testing"
@@ -10,6 +13,18 @@ import (
 	picrosssolver "github.com/inahym196/picross-solver"
 )
 
+// 行・列のルールだけで解ける 15×15 のパズル
+const (
+	largeRowHints = "2-3-1-2-3 1-2-4-1 1-2-5 3-2-2-1 1-1-2-1-1 4-1-1-2 5-1-1-3 5-1-1-3 2-1-1-1-1-1 1-1-1-1-1-1 2-1-3 1-8-1 0 1-1-1-1-1-1 2-2"
+	largeColHints = "1-8-2 1-1-4-1-1 1-3-1 2-4-3-1 1-1-3-1 4-1 1-2-3-1 1-5-1 2-1 3-6-1 6-2 3-3-1 1-1-2 2-4-1-1 1-1-5-2"
+)
+
+// 行・列のルールだけでは解けず、仮定が要る 5×5 のパズル
+const (
+	hypothesisRowHints = "1 2 2-1 2-1 1-1"
+	hypothesisColHints = "1-1 3 2 1-2 1"
+)
+
 func ParseHints(s string) [][]int {
 	fields := strings.Fields(s)
 	hints := make([][]int, 0, len(fields))
@@ -42,6 +57,8 @@ func TestE2E(t *testing.T) {
 		rowHints [][]int
 		colHints [][]int
 		expected []string
+		// 行・列のルールだけでは解けない
+		hypothesis bool
 	}{
 		{
 			rowHints: ParseHints("0 2"),
@@ -91,9 +108,21 @@ func TestE2E(t *testing.T) {
 				"_#___",
 				"__#__",
 			},
+		},
+		{
+			rowHints: ParseHints(hypothesisRowHints),
+			colHints: ParseHints(hypothesisColHints),
+			expected: []string{
+				"#____",
+				"__##_",
+				"_##_#",
+				"##_#_",
+				"_#_#_",
+			},
+			hypothesis: true,
 		}, {
-			rowHints: ParseHints("2-3-1-2-3 1-2-4-1 1-2-5 3-2-2-1 1-1-2-1-1 4-1-1-2 5-1-1-3 5-1-1-3 2-1-1-1-1-1 1-1-1-1-1-1 2-1-3 1-8-1 0 1-1-1-1-1-1 2-2"),
-			colHints: ParseHints("1-8-2 1-1-4-1-1 1-3-1 2-4-3-1 1-1-3-1 4-1 1-2-3-1 1-5-1 2-1 3-6-1 6-2 3-3-1 1-1-2 2-4-1-1 1-1-5-2"),
+			rowHints: ParseHints(largeRowHints),
+			colHints: ParseHints(largeColHints),
 			expected: []string{
 				"##_###_#_##_###",
 				"___#_##_####_#_",
@@ -113,13 +142,19 @@ func TestE2E(t *testing.T) {
 			},
 		},
 	}
-	solver := picrosssolver.NewSolver()
 	for i, tt := range tests {
 		t.Run(fmt.Sprintf("case%d", i), func(t *testing.T) {
+			solver := picrosssolver.NewSolver()
+			if tt.hypothesis {
+				solver = picrosssolver.NewSolver(picrosssolver.WithHypothesis())
+			}
 			game, _ := picrosssolver.NewGame(tt.rowHints, tt.colHints)
 
-			n, deds := solver.ApplyMany(game)
+			n, deds, err := solver.ApplyMany(game)
 			t.Logf("applied x%d\n", n)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
 
 			boardStrings := game.PrintBoard()
 			if !reflect.DeepEqual(boardStrings, tt.expected) {
@@ -134,10 +169,676 @@ func TestE2E(t *testing.T) {
 	}
 }
 
+func TestApplyManyQueued(t *testing.T) {
+	tests := []struct {
+		rowHints [][]int
+		colHints [][]int
+	}{
+		{ParseHints("0 2"), ParseHints("1 1")},
+		{ParseHints("1-1-1 1-1-1 5 5 5"), ParseHints("5 3 5 3 5")},
+		{ParseHints(hypothesisRowHints), ParseHints(hypothesisColHints)},
+		{ParseHints("1 1"), ParseHints("1 1")},
+		{
+			ParseHints(largeRowHints),
+			ParseHints(largeColHints),
+		},
+	}
+	solver := picrosssolver.NewSolver(picrosssolver.WithHypothesis())
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("case%d", i), func(t *testing.T) {
+			swept, _ := picrosssolver.NewGame(tt.rowHints, tt.colHints)
+			queued, _ := picrosssolver.NewGame(tt.rowHints, tt.colHints)
+
+			n, sweptDeds, err := solver.ApplyMany(swept)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			stats, queuedDeds, err := solver.ApplyManyQueued(queued)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !slices.Equal(swept.PrintBoard(), queued.PrintBoard()) {
+				t.Errorf("expected %v, got %v", swept.PrintBoard(), queued.PrintBoard())
+			}
+			if !reflect.DeepEqual(sweptDeds, queuedDeds) {
+				t.Errorf("trace differs:\n%v\n%v", sweptDeds, queuedDeds)
+			}
+			if stats.Passes != n {
+				t.Errorf("expected %d passes, got %d", n, stats.Passes)
+			}
+			lines := len(tt.rowHints) + len(tt.colHints)
+			if stats.Evaluations+stats.Skipped != (n+1)*lines {
+				t.Errorf("expected %d evaluations+skipped, got %+v", (n+1)*lines, stats)
+			}
+		})
+	}
+}
+
+// 左端が未確定なら白にするだけのテスト用ルール
+type leftWhiteRule struct{}
+
+func (r leftWhiteRule) Name() string { return "LeftWhiteRule" }
+
+func (r leftWhiteRule) Deduce(line picrosssolver.Line) []picrosssolver.Cell {
+	if line.Cells[0] != picrosssolver.CellUndetermined || line.Hints[0] != 0 {
+		return nil
+	}
+	cells := slices.Clone(line.Cells)
+	cells[0] = picrosssolver.CellWhite
+	return cells
+}
+
+func TestSolverRuleOptions(t *testing.T) {
+	tests := []struct {
+		opts     []picrosssolver.Option
+		expected []string
+	}{
+		{nil, []string{"ZeroHintRule", "MinimumSpacingRule", "OverlapFillRule", "OverlapExpansionRule", "EdgeExpansionRule", "BlockSatisfiedRule", "PruneImpossibleSegmentRule", "FillRemainingWhiteRule"}},
+		{
+			[]picrosssolver.Option{picrosssolver.WithRules(picrosssolver.ExhaustivePlacementRule{}, leftWhiteRule{})},
+			[]string{"ExhaustivePlacementRule", "LeftWhiteRule"},
+		},
+		{
+			[]picrosssolver.Option{picrosssolver.WithRules(picrosssolver.DefaultRules()[:3]...), picrosssolver.WithoutRule("MinimumSpacingRule")},
+			[]string{"ZeroHintRule", "OverlapFillRule"},
+		},
+		{
+			[]picrosssolver.Option{picrosssolver.WithRules(picrosssolver.DefaultRules()[:3]...), picrosssolver.WithRuleOrder("OverlapFillRule", "ZeroHintRule")},
+			[]string{"OverlapFillRule", "ZeroHintRule", "MinimumSpacingRule"},
+		},
+		// 該当するルールが無い名前は無視する
+		{
+			[]picrosssolver.Option{picrosssolver.WithRules(picrosssolver.DefaultRules()[:3]...), picrosssolver.WithoutRule("NoSuchRule")},
+			[]string{"ZeroHintRule", "MinimumSpacingRule", "OverlapFillRule"},
+		},
+		{
+			[]picrosssolver.Option{picrosssolver.WithRules(picrosssolver.DefaultRules()[:3]...), picrosssolver.WithRuleOrder("NoSuchRule", "OverlapFillRule")},
+			[]string{"OverlapFillRule", "ZeroHintRule", "MinimumSpacingRule"},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("case%d", i), func(t *testing.T) {
+			got := picrosssolver.NewSolver(tt.opts...).RuleNames()
+
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSolverCustomRules(t *testing.T) {
+	solver := picrosssolver.NewSolver(picrosssolver.WithRules(leftWhiteRule{}, picrosssolver.ExhaustivePlacementRule{}))
+	game, _ := picrosssolver.NewGame(ParseHints("0 2"), ParseHints("1 1"))
+
+	_, deds, err := solver.ApplyMany(game)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"__", "##"}
+	if !slices.Equal(game.PrintBoard(), expected) {
+		t.Errorf("expected %v, got %v", expected, game.PrintBoard())
+	}
+	if deds[0].Rule() != "LeftWhiteRule" {
+		t.Errorf("expected LeftWhiteRule first, got %s", deds[0])
+	}
+}
+
+func TestSolverRulesColor(t *testing.T) {
+	r := func(n int) picrosssolver.ColorHint { return picrosssolver.ColorHint{Length: n, Color: 1} }
+	game, _ := picrosssolver.NewColorGame([][]picrosssolver.ColorHint{{r(2)}}, [][]picrosssolver.ColorHint{{r(1)}, {r(1)}})
+
+	// 多色の行・列はルールの指定にかかわらず ExhaustivePlacementRule で推論する
+	_, deds, err := picrosssolver.NewSolver(picrosssolver.WithRules(leftWhiteRule{})).ApplyMany(game)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if deds[0].Rule() != "ExhaustivePlacementRule" {
+		t.Errorf("expected ExhaustivePlacementRule, got %s", deds[0])
+	}
+	if expected := []string{"11"}; !slices.Equal(game.PrintBoard(), expected) {
+		t.Errorf("expected %v, got %v", expected, game.PrintBoard())
+	}
+}
+
+func TestWithLineCache(t *testing.T) {
+	rowHints := ParseHints(largeRowHints)
+	colHints := ParseHints(largeColHints)
+	plain := picrosssolver.NewSolver()
+	cached := picrosssolver.NewSolver(picrosssolver.WithLineCache(1000))
+
+	expectedGame, _ := picrosssolver.NewGame(rowHints, colHints)
+	_, expectedDeds, _ := plain.ApplyMany(expectedGame)
+
+	for i := range 2 {
+		game, _ := picrosssolver.NewGame(rowHints, colHints)
+		_, deds, err := cached.ApplyMany(game)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(expectedGame.PrintBoard(), game.PrintBoard()) {
+			t.Errorf("run%d: expected %v, got %v", i, expectedGame.PrintBoard(), game.PrintBoard())
+		}
+		if !reflect.DeepEqual(expectedDeds, deds) {
+			t.Errorf("run%d: trace differs", i)
+		}
+	}
+
+	stats := cached.CacheStats()
+	if stats.Hits == 0 || stats.Misses == 0 {
+		t.Errorf("expected both hits and misses, got %+v", stats)
+	}
+	if stats.Entries != stats.Misses {
+		t.Errorf("expected %d entries, got %+v", stats.Misses, stats)
+	}
+	if got := plain.CacheStats(); got != (picrosssolver.CacheStats{}) {
+		t.Errorf("expected zero stats without cache, got %+v", got)
+	}
+}
+
+func TestUseBitBoard(t *testing.T) {
+	rowHints := ParseHints(largeRowHints)
+	colHints := ParseHints(largeColHints)
+	solver := picrosssolver.NewSolver()
+
+	sliced, _ := picrosssolver.NewGame(rowHints, colHints)
+	bits, _ := picrosssolver.NewGame(rowHints, colHints)
+	if err := bits.UseBitBoard(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, slicedDeds, _ := solver.ApplyMany(sliced)
+	_, bitsDeds, err := solver.ApplyMany(bits)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(sliced.PrintBoard(), bits.PrintBoard()) {
+		t.Errorf("expected %v, got %v", sliced.PrintBoard(), bits.PrintBoard())
+	}
+	if !reflect.DeepEqual(slicedDeds, bitsDeds) {
+		t.Errorf("trace differs")
+	}
+
+	color, _ := picrosssolver.NewColorGame(
+		[][]picrosssolver.ColorHint{{{Length: 1, Color: 1}}},
+		[][]picrosssolver.ColorHint{{{Length: 1, Color: 1}}},
+	)
+	if err := color.UseBitBoard(); !errors.Is(err, picrosssolver.ErrUnsupportedColor) {
+		t.Errorf("expected ErrUnsupportedColor, got %v", err)
+	}
+}
+
+func TestColorE2E(t *testing.T) {
+	b := func(n int) picrosssolver.ColorHint {
+		return picrosssolver.ColorHint{Length: n, Color: picrosssolver.ColorBlack}
+	}
+	r := func(n int) picrosssolver.ColorHint { return picrosssolver.ColorHint{Length: n, Color: 1} }
+
+	tests := []struct {
+		rowHints [][]picrosssolver.ColorHint
+		colHints [][]picrosssolver.ColorHint
+		expected []string
+		// 行・列のルールだけでは解けない
+		hypothesis bool
+	}{
+		{
+			rowHints: [][]picrosssolver.ColorHint{{b(2), r(1)}, {r(2)}, {b(2)}},
+			colHints: [][]picrosssolver.ColorHint{{b(1), r(1)}, {b(1), r(1), b(1)}, {r(1), b(1)}},
+			expected: []string{
+				"##1",
+				"11_",
+				"_##",
+			},
+			hypothesis: true,
+		},
+		{
+			rowHints: [][]picrosssolver.ColorHint{{r(1), b(1), r(1)}, {b(3)}, {r(1), b(1), r(1)}},
+			colHints: [][]picrosssolver.ColorHint{{r(1), b(1), r(1)}, {b(3)}, {r(1), b(1), r(1)}},
+			expected: []string{
+				"1#1",
+				"###",
+				"1#1",
+			},
+		},
+		{
+			rowHints: [][]picrosssolver.ColorHint{{b(1), b(1)}, {b(1)}},
+			colHints: [][]picrosssolver.ColorHint{{b(1)}, {b(1)}, {b(1)}},
+			expected: []string{
+				"#_#",
+				"_#_",
+			},
+		},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("case%d", i), func(t *testing.T) {
+			solver := picrosssolver.NewSolver()
+			if tt.hypothesis {
+				solver = picrosssolver.NewSolver(picrosssolver.WithHypothesis())
+			}
+			game, err := picrosssolver.NewColorGame(tt.rowHints, tt.colHints)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if _, _, err := solver.ApplyMany(game); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !slices.Equal(game.PrintBoard(), tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, game.PrintBoard())
+			}
+			if u, _ := solver.CheckUniqueness(game); u != picrosssolver.UniquenessUnique {
+				t.Errorf("expected unique, got %s", u)
+			}
+		})
+	}
+}
+
+func TestSolve(t *testing.T) {
+	tests := []struct {
+		rowHints [][]int
+		colHints [][]int
+		expected [][]string
+	}{
+		{
+			rowHints: ParseHints("1 1"),
+			colHints: ParseHints("1 1"),
+			expected: [][]string{{"#_", "_#"}, {"_#", "#_"}},
+		},
+		{
+			rowHints: ParseHints("1-1 3 1-1"),
+			colHints: ParseHints("3 1 3"),
+			expected: [][]string{{"#_#", "###", "#_#"}},
+		},
+		{
+			rowHints: ParseHints(largeRowHints),
+			colHints: ParseHints(largeColHints),
+			expected: [][]string{{
+				"##_###_#_##_###",
+				"___#_##_####_#_",
+				"#___##__#####__",
+				"###__##___##__#",
+				"#__#__##__#__#_",
+				"####___#__#__##",
+				"#####__#_#__###",
+				"#####__#_#__###",
+				"##__#__#_#_#__#",
+				"#__#__#__#_#__#",
+				"__##__#__###___",
+				"_#_########__#_",
+				"_______________",
+				"#__#__#__#_#__#",
+				"##___________##",
+			}},
+		},
+	}
+	solver := picrosssolver.NewSolver()
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("case%d", i), func(t *testing.T) {
+			game, _ := picrosssolver.NewGame(tt.rowHints, tt.colHints)
+
+			board, err := solver.Solve(game)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := board.Print()
+			if !slices.ContainsFunc(tt.expected, func(e []string) bool { return slices.Equal(e, got) }) {
+				t.Errorf("expected one of %v, got %v", tt.expected, got)
+			}
+			if !slices.Equal(game.PrintBoard(), got) {
+				t.Errorf("game board %v differs from solution %v", game.PrintBoard(), got)
+			}
+		})
+	}
+}
+
+func TestSolveNoSolution(t *testing.T) {
+	game, _ := picrosssolver.NewGame(ParseHints("2 2"), ParseHints("2 0 2"))
+
+	if _, err := picrosssolver.NewSolver().Solve(game); !errors.Is(err, picrosssolver.ErrNoSolution) {
+		t.Errorf("expected ErrNoSolution, got %v", err)
+	}
+}
+
+func TestEnumerate(t *testing.T) {
+	game, _ := picrosssolver.NewGame(ParseHints("1 1 1"), ParseHints("1 1 1"))
+
+	tests := []struct {
+		limit    int
+		expected int
+	}{
+		{0, 6},
+		{1, 1},
+		{4, 4},
+		{10, 6},
+	}
+	solver := picrosssolver.NewSolver()
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("case%d", i), func(t *testing.T) {
+			boards := solver.Enumerate(game, tt.limit)
+
+			if len(boards) != tt.expected {
+				t.Fatalf("expected %d boards, got %d", tt.expected, len(boards))
+			}
+			seen := map[string]bool{}
+			for _, b := range boards {
+				key := strings.Join(b.Print(), "/")
+				if seen[key] {
+					t.Errorf("duplicated board %s", key)
+				}
+				seen[key] = true
+			}
+		})
+	}
+	if !slices.Equal(game.PrintBoard(), []string{"???", "???", "???"}) {
+		t.Errorf("Enumerate mutated game: %v", game.PrintBoard())
+	}
+}
+
+func TestCheckUniqueness(t *testing.T) {
+	tests := []struct {
+		rowHints [][]int
+		colHints [][]int
+		expected picrosssolver.Uniqueness
+		boards   int
+	}{
+		{ParseHints("1-1 3 1-1"), ParseHints("3 1 3"), picrosssolver.UniquenessUnique, 1},
+		{ParseHints("1 1"), ParseHints("1 1"), picrosssolver.UniquenessMultiple, 2},
+		{ParseHints("2 2"), ParseHints("2 0 2"), picrosssolver.UniquenessNone, 0},
+	}
+	solver := picrosssolver.NewSolver()
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("case%d", i), func(t *testing.T) {
+			game, _ := picrosssolver.NewGame(tt.rowHints, tt.colHints)
+
+			got, boards := solver.CheckUniqueness(game)
+
+			if got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+			if len(boards) != tt.boards {
+				t.Errorf("expected %d boards, got %d", tt.boards, len(boards))
+			}
+		})
+	}
+}
+
+func TestSearchContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	solver := picrosssolver.NewSolver()
+	newGame := func() *picrosssolver.Game {
+		game, _ := picrosssolver.NewGame(ParseHints("1 1 1"), ParseHints("1 1 1"))
+		return game
+	}
+
+	if _, err := solver.SolveContext(ctx, newGame()); !errors.Is(err, context.Canceled) {
+		t.Errorf("SolveContext: expected context.Canceled, got %v", err)
+	}
+	if boards, err := solver.EnumerateContext(ctx, newGame(), 0); !errors.Is(err, context.Canceled) || len(boards) != 0 {
+		t.Errorf("EnumerateContext: expected context.Canceled, got %v %v", boards, err)
+	}
+	if _, _, err := solver.CheckUniquenessContext(ctx, newGame()); !errors.Is(err, context.Canceled) {
+		t.Errorf("CheckUniquenessContext: expected context.Canceled, got %v", err)
+	}
+	if _, err := solver.RateContext(ctx, newGame()); !errors.Is(err, context.Canceled) {
+		t.Errorf("RateContext: expected context.Canceled, got %v", err)
+	}
+	if _, _, err := solver.ApplyManyQueuedContext(ctx, newGame()); !errors.Is(err, context.Canceled) {
+		t.Errorf("ApplyManyQueuedContext: expected context.Canceled, got %v", err)
+	}
+}
+
+func TestDeductionTrace(t *testing.T) {
+	game, _ := picrosssolver.NewGame(ParseHints("0 2"), ParseHints("1 1"))
+
+	deds, err := picrosssolver.NewSolver().ApplyOnce(game)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got []string
+	for _, ded := range deds {
+		got = append(got, ded.String())
+	}
+	expected := []string{
+		"ZeroHintRule Row[0] [0] [U U] -> [W W]",
+		"MinimumSpacingRule Row[1] [2] [U U] -> [B B]",
+	}
+	if !slices.Equal(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+
+	ded := deds[1]
+	if ded.Rule() != "MinimumSpacingRule" {
+		t.Errorf("expected MinimumSpacingRule, got %s", ded.Rule())
+	}
+	if ded.Line() != (picrosssolver.LineRef{Kind: picrosssolver.LineKindRow, Index: 1}) {
+		t.Errorf("expected Row[1], got %s", ded.Line())
+	}
+	if ded.Kind() != picrosssolver.LineKindRow || ded.Index() != 1 {
+		t.Errorf("expected Row 1, got %s %d", ded.Kind(), ded.Index())
+	}
+	if !slices.Equal(ded.Hints(), []int{2}) {
+		t.Errorf("expected [2], got %v", ded.Hints())
+	}
+	if !slices.Equal(ded.Before(), []picrosssolver.Cell{picrosssolver.CellUndetermined, picrosssolver.CellUndetermined}) {
+		t.Errorf("unexpected before %v", ded.Before())
+	}
+	if !slices.Equal(ded.After(), []picrosssolver.Cell{picrosssolver.CellBlack, picrosssolver.CellBlack}) {
+		t.Errorf("unexpected after %v", ded.After())
+	}
+	if !slices.Equal(ded.ChangedCells(), []int{0, 1}) {
+		t.Errorf("expected [0 1], got %v", ded.ChangedCells())
+	}
+	if _, ok := ded.Probe(); ok {
+		t.Errorf("expected no probe on %s", ded)
+	}
+
+	// 仮定による推論は、どのセルに何を仮定したかを持つ
+	game, _ = picrosssolver.NewGame(ParseHints(hypothesisRowHints), ParseHints(hypothesisColHints))
+	_, deds, err = picrosssolver.NewSolver(picrosssolver.WithHypothesis()).ApplyMany(game)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got = nil
+	for _, ded := range deds {
+		if ded.Rule() == "HypothesisRule" {
+			got = append(got, ded.String())
+		}
+	}
+	expected = []string{
+		"HypothesisRule Row[0] [1] [U U U U U] -> [U W U U U] probe (0,1) [W]",
+		"HypothesisRule Row[0] [1] [U W U U U] -> [U W W U U] probe (0,2) [W]",
+		"HypothesisRule Row[0] [1] [U W W U U] -> [U W W U W] probe (0,3) [B W]",
+		"HypothesisRule Row[1] [2] [U U U U U] -> [U U U U W] probe (0,3) [B W]",
+	}
+	if len(got) < len(expected) || !slices.Equal(got[:len(expected)], expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+	i := slices.IndexFunc(deds, func(d picrosssolver.Deduction) bool { return d.Rule() == "HypothesisRule" })
+	probe, ok := deds[i].Probe()
+	if !ok || probe.Row != 0 || probe.Col != 1 || !slices.Equal(probe.Survivors, []picrosssolver.Cell{picrosssolver.CellWhite}) {
+		t.Errorf("unexpected probe %v %v", probe, ok)
+	}
+}
+
+func TestContradiction(t *testing.T) {
+	tests := []struct {
+		rowHints [][]int
+		colHints [][]int
+		line     string
+		// 仮定で初めて矛盾が分かる
+		hypothesis bool
+	}{
+		{
+			rowHints: ParseHints("2 2"),
+			colHints: ParseHints("2 0 2"),
+			line:     "Col[1]",
+		},
+		{
+			rowHints: ParseHints("1-1 0 1"),
+			colHints: ParseHints("2 0 1"),
+			line:     "Col[0]",
+		},
+		{
+			rowHints: ParseHints("2 0 1"),
+			colHints: ParseHints("1-1 0 1"),
+			line:     "Col[1]",
+		},
+		// 行・列のルールでは矛盾が出ず、仮定でどの値も矛盾するセルがある
+		{
+			rowHints:   ParseHints("1 1 2 2"),
+			colHints:   ParseHints("2 1 1 2"),
+			line:       "Row[0]",
+			hypothesis: true,
+		},
+		{
+			rowHints:   ParseHints("2 2 2 1-1"),
+			colHints:   ParseHints("2 1-1 2 2"),
+			line:       "Row[0]",
+			hypothesis: true,
+		},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("case%d", i), func(t *testing.T) {
+			solver := picrosssolver.NewSolver()
+			if tt.hypothesis {
+				solver = picrosssolver.NewSolver(picrosssolver.WithHypothesis())
+			}
+			game, _ := picrosssolver.NewGame(tt.rowHints, tt.colHints)
+
+			_, _, err := solver.ApplyMany(game)
+
+			if !errors.Is(err, picrosssolver.ErrContradiction) {
+				t.Fatalf("expected ErrContradiction, got %v", err)
+			}
+			var ce *picrosssolver.ContradictionError
+			if !errors.As(err, &ce) {
+				t.Fatalf("expected *ContradictionError, got %T", err)
+			}
+			if ce.Line.String() != tt.line {
+				t.Errorf("expected %s, got %s", tt.line, ce.Line)
+			}
+		})
+	}
+}
+
+// 最初の推論で cancel を呼ぶルール
+type cancelRule struct {
+	cancel context.CancelFunc
+}
+
+func (r cancelRule) Name() string { return "CancelRule" }
+
+func (r cancelRule) Deduce(line picrosssolver.Line) []picrosssolver.Cell {
+	r.cancel()
+	return nil
+}
+
+func TestApplyManyContext(t *testing.T) {
+	t.Run("canceled", func(t *testing.T) {
+		game, _ := picrosssolver.NewGame(ParseHints("0 2"), ParseHints("1 1"))
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		n, deds, err := picrosssolver.NewSolver().ApplyManyContext(ctx, game)
+
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+		if n != 0 || len(deds) != 0 {
+			t.Errorf("expected no progress, got n=%d deds=%v", n, deds)
+		}
+	})
+	t.Run("between lines", func(t *testing.T) {
+		game, _ := picrosssolver.NewGame(ParseHints("0 2"), ParseHints("1 1"))
+		ctx, cancel := context.WithCancel(context.Background())
+		solver := picrosssolver.NewSolver(picrosssolver.WithRules(cancelRule{cancel}, picrosssolver.ExhaustivePlacementRule{}))
+
+		_, deds, err := solver.ApplyManyContext(ctx, game)
+
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+		// Row[0] の評価は終わり、Row[1] の前で止まる
+		expected := []string{"ExhaustivePlacementRule Row[0] [0] [U U] -> [W W]"}
+		var got []string
+		for _, ded := range deds {
+			got = append(got, ded.String())
+		}
+		if !reflect.DeepEqual(expected, got) {
+			t.Errorf("expected %v, got %v", expected, got)
+		}
+	})
+	t.Run("background", func(t *testing.T) {
+		game, _ := picrosssolver.NewGame(ParseHints(hypothesisRowHints), ParseHints(hypothesisColHints))
+		expected, _ := picrosssolver.NewGame(ParseHints(hypothesisRowHints), ParseHints(hypothesisColHints))
+		solver := picrosssolver.NewSolver()
+		wantN, wantDeds, _ := solver.ApplyMany(expected)
+
+		n, deds, err := solver.ApplyManyContext(context.Background(), game)
+
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if n != wantN || !reflect.DeepEqual(wantDeds, deds) {
+			t.Errorf("expected same trace as ApplyMany")
+		}
+	})
+}
+
+func TestSolverLimits(t *testing.T) {
+	rowHints := ParseHints(largeRowHints)
+	colHints := ParseHints(largeColHints)
+	game, _ := picrosssolver.NewGame(rowHints, colHints)
+	total, all, _ := picrosssolver.NewSolver().ApplyMany(game)
+
+	tests := []struct {
+		opt      picrosssolver.Option
+		expected error
+		n        int
+	}{
+		{picrosssolver.WithMaxIterations(1), picrosssolver.ErrIterationLimit, 1},
+		{picrosssolver.WithMaxIterations(total), nil, total},
+		{picrosssolver.WithMaxIterations(0), nil, total},
+		{picrosssolver.WithMaxDeductions(1), picrosssolver.ErrDeductionLimit, 1},
+		{picrosssolver.WithMaxDeductions(len(all)), nil, total},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("case%d", i), func(t *testing.T) {
+			game, _ := picrosssolver.NewGame(rowHints, colHints)
+
+			n, _, err := picrosssolver.NewSolver(tt.opt).ApplyMany(game)
+
+			if !errors.Is(err, tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, err)
+			}
+			if n != tt.n {
+				t.Errorf("expected %v, got %v", tt.n, n)
+			}
+
+			queued, _ := picrosssolver.NewGame(rowHints, colHints)
+			stats, _, err := picrosssolver.NewSolver(tt.opt).ApplyManyQueued(queued)
+			if !errors.Is(err, tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, err)
+			}
+			if stats.Passes != tt.n {
+				t.Errorf("expected %v, got %v", tt.n, stats.Passes)
+			}
+		})
+	}
+}
+
 func BenchmarkE2E(b *testing.B) {
 
-	rowHints := ParseHints("2-3-1-2-3 1-2-4-1 1-2-5 3-2-2-1 1-1-2-1-1 4-1-1-2 5-1-1-3 5-1-1-3 2-1-1-1-1-1 1-1-1-1-1-1 2-1-3 1-8-1 0 1-1-1-1-1-1 2-2")
-	colHints := ParseHints("1-8-2 1-1-4-1-1 1-3-1 2-4-3-1 1-1-3-1 4-1 1-2-3-1 1-5-1 2-1 3-6-1 6-2 3-3-1 1-1-2 2-4-1-1 1-1-5-2")
+	rowHints := ParseHints(largeRowHints)
+	colHints := ParseHints(largeColHints)
 	solver := picrosssolver.NewSolver()
 	game, _ := picrosssolver.NewGame(rowHints, colHints)
 
@@ -146,3 +847,27 @@ func BenchmarkE2E(b *testing.B) {
 	}
 
 }
+
+// BenchmarkE2EBitBoard と同じく毎回新しい盤面から解く
+func BenchmarkE2EFresh(b *testing.B) {
+	rowHints := ParseHints(largeRowHints)
+	colHints := ParseHints(largeColHints)
+	solver := picrosssolver.NewSolver()
+
+	for b.Loop() {
+		game, _ := picrosssolver.NewGame(rowHints, colHints)
+		solver.ApplyMany(game)
+	}
+}
+
+func BenchmarkE2EBitBoard(b *testing.B) {
+	rowHints := ParseHints(largeRowHints)
+	colHints := ParseHints(largeColHints)
+	solver := picrosssolver.NewSolver()
+
+	for b.Loop() {
+		game, _ := picrosssolver.NewGame(rowHints, colHints)
+		game.UseBitBoard()
+		solver.ApplyMany(game)
+	}
+}
@@ -0,0 +1,297 @@
+package picrosssolver
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	ErrUnsupportedColor = errors.New("白黒以外の色には未対応")
+	ErrUnknownColor     = errors.New("定義されていない色")
+)
+
+type pbnPuzzleSet struct {
+	XMLName xml.Name    `xml:"puzzleset"`
+	Puzzles []pbnPuzzle `xml:"puzzle"`
+}
+
+type pbnPuzzle struct {
+	Type            string        `xml:"type,attr,omitempty"`
+	DefaultColor    string        `xml:"defaultcolor,attr,omitempty"`
+	BackgroundColor string        `xml:"backgroundcolor,attr,omitempty"`
+	Source          string        `xml:"source,omitempty"`
+	ID              string        `xml:"id,omitempty"`
+	Title           string        `xml:"title,omitempty"`
+	Colors          []pbnColor    `xml:"color"`
+	Clues           []pbnClues    `xml:"clues"`
+	Solutions       []pbnSolution `xml:"solution"`
+}
+
+type pbnColor struct {
+	Name  string `xml:"name,attr"`
+	Char  string `xml:"char,attr,omitempty"`
+	Value string `xml:",chardata"`
+}
+
+type pbnClues struct {
+	Type  string    `xml:"type,attr"`
+	Lines []pbnLine `xml:"line"`
+}
+
+type pbnLine struct {
+	Counts []pbnCount `xml:"count"`
+}
+
+type pbnCount struct {
+	Color string `xml:"color,attr,omitempty"`
+	Value int    `xml:",chardata"`
+}
+
+type pbnSolution struct {
+	Type  string   `xml:"type,attr,omitempty"`
+	Image pbnImage `xml:"image"`
+}
+
+// 改行を実体参照にせずそのまま書き出すため innerxml で保持する
+type pbnImage struct {
+	Data string `xml:",innerxml"`
+}
+
+func (p pbnPuzzle) colorName(name, fallback string) string {
+	if name == "" {
+		return fallback
+	}
+	return name
+}
+
+// 色名から image で使う文字を引く
+func (p pbnPuzzle) colorChar(name, fallback string) string {
+	for _, c := range p.Colors {
+		if c.Name == name && c.Char != "" {
+			return c.Char
+		}
+	}
+	return fallback
+}
+
+// 色名から Color を引く表。既定の色を黒とし、背景以外の色を <color> の順に 1 から番号付ける
+func (p pbnPuzzle) palette() map[string]Color {
+	background := p.colorName(p.BackgroundColor, "white")
+	palette := map[string]Color{p.colorName(p.DefaultColor, "black"): ColorBlack}
+	next := ColorBlack + 1
+	for _, c := range p.Colors {
+		if _, ok := palette[c.Name]; ok || c.Name == background {
+			continue
+		}
+		palette[c.Name] = next
+		next++
+	}
+	return palette
+}
+
+// ヒントとその色を返す。黒だけなら色は nil
+func (p pbnPuzzle) hints(kind LineKind, palette map[string]Color) ([][]int, [][]Color, error) {
+	clueType := map[LineKind]string{LineKindRow: "rows", LineKindColumn: "columns"}[kind]
+	defaultColor := p.colorName(p.DefaultColor, "black")
+
+	for _, clues := range p.Clues {
+		if clues.Type != clueType {
+			continue
+		}
+		hints := make([][]int, len(clues.Lines))
+		colors := make([][]Color, len(clues.Lines))
+		monochrome := true
+		for i, line := range clues.Lines {
+			if len(line.Counts) == 0 {
+				hints[i] = []int{0}
+				colors[i] = []Color{ColorBlack}
+				continue
+			}
+			for _, count := range line.Counts {
+				name := p.colorName(count.Color, defaultColor)
+				color, ok := palette[name]
+				if !ok {
+					return nil, nil, fmt.Errorf("%s: %w: %q", LineRef{kind, i}, ErrUnknownColor, name)
+				}
+				monochrome = monochrome && color == ColorBlack
+				hints[i] = append(hints[i], count.Value)
+				colors[i] = append(colors[i], color)
+			}
+		}
+		if monochrome {
+			colors = nil
+		}
+		return hints, colors, nil
+	}
+	return nil, nil, fmt.Errorf("clues type=%q が無い", clueType)
+}
+
+func (p pbnPuzzle) solution(height, width int, palette map[string]Color) (Board, error) {
+	for _, sol := range p.Solutions {
+		if sol.Type != "" && sol.Type != "goal" {
+			continue
+		}
+		cells := map[string]Cell{p.colorChar(p.colorName(p.BackgroundColor, "white"), "."): CellWhite}
+		for name, color := range palette {
+			fallback := ""
+			if color == ColorBlack {
+				fallback = "X"
+			}
+			if char := p.colorChar(name, fallback); char != "" {
+				cells[char] = ColorCell(color)
+			}
+		}
+
+		var rows []string
+		for _, line := range strings.Split(sol.Image.Data, "\n") {
+			if line = strings.Trim(strings.TrimSpace(line), "|"); line != "" {
+				rows = append(rows, line)
+			}
+		}
+		if len(rows) != height {
+			return nil, fmt.Errorf("solution の行数が %d ではない: %d", height, len(rows))
+		}
+		board := newBoard(height, width)
+		for i, row := range rows {
+			if len(row) != width {
+				return nil, fmt.Errorf("solution の %d 行目の長さが %d ではない: %d", i, width, len(row))
+			}
+			for j, r := range row {
+				c, ok := cells[string(r)]
+				if !ok {
+					return nil, fmt.Errorf("solution の %d 行目: %w: %q", i, ErrUnknownColor, r)
+				}
+				board[i][j] = c
+			}
+		}
+		return board, nil
+	}
+	return nil, nil
+}
+
+// webpbn の XML 形式を読み込む。複数のパズルがあれば先頭を使い、solution が無ければ nil を返す。
+// 色付きのヒントは既定の色を黒とし、背景以外の色を <color> の順に 1 から番号付けた多色のパズルにする
+func ReadWebpbn(r io.Reader) (*Game, Board, error) {
+	var set pbnPuzzleSet
+	if err := xml.NewDecoder(r).Decode(&set); err != nil {
+		return nil, nil, err
+	}
+	if len(set.Puzzles) == 0 {
+		return nil, nil, errors.New("puzzle が無い")
+	}
+	puzzle := set.Puzzles[0]
+	palette := puzzle.palette()
+
+	rowHints, rowColors, err := puzzle.hints(LineKindRow, palette)
+	if err != nil {
+		return nil, nil, err
+	}
+	colHints, colColors, err := puzzle.hints(LineKindColumn, palette)
+	if err != nil {
+		return nil, nil, err
+	}
+	game, err := newGame(rowHints, colHints, rowColors, colColors)
+	if err != nil {
+		return nil, nil, err
+	}
+	solution, err := puzzle.solution(len(rowHints), len(colHints), palette)
+	if err != nil {
+		return nil, nil, err
+	}
+	return game, solution, nil
+}
+
+// 書き出すときの黒以外の色の文字。image で黒に使う X を除いて colorSymbols と同じ順に並べる
+var pbnColorChars = strings.Replace(colorSymbols, "X", "", 1) + "+"
+
+// 書き出すときの黒以外の色の値。色の番号の順に繰り返し割り当てる
+var pbnColorValues = []string{"d62728", "1f77b4", "2ca02c", "ff7f0e", "9467bd", "8c564b", "e377c2", "17becf"}
+
+// 書き出すときの黒以外の色。名前と文字は色の番号から決める
+func pbnColorOf(c Color) pbnColor {
+	return pbnColor{
+		Name:  fmt.Sprintf("color%d", c),
+		Char:  string(pbnColorChars[c-1]),
+		Value: pbnColorValues[int(c-1)%len(pbnColorValues)],
+	}
+}
+
+func newPbnClues(clueType string, hintsList [][]int, colorsList [][]Color) pbnClues {
+	clues := pbnClues{Type: clueType, Lines: make([]pbnLine, len(hintsList))}
+	for i, hints := range hintsList {
+		for j, h := range blockHints(hints) {
+			count := pbnCount{Value: h}
+			if colorsList != nil && colorsList[i][j] != ColorBlack {
+				count.Color = pbnColorOf(colorsList[i][j]).Name
+			}
+			clues.Lines[i].Counts = append(clues.Lines[i].Counts, count)
+		}
+	}
+	return clues
+}
+
+// 使われている最大の色の番号
+func maxColor(colorsLists ...[][]Color) Color {
+	var m Color
+	for _, colorsList := range colorsLists {
+		for _, colors := range colorsList {
+			for _, c := range colors {
+				m = max(m, c)
+			}
+		}
+	}
+	return m
+}
+
+// webpbn の XML 形式で書き出す。solution が nil なら solution 要素は出力しない。
+// 黒以外の色は color1, color2, ... の名前で、番号が変わらないよう 1 から最大の番号まで全て書き出す
+func WriteWebpbn(w io.Writer, game *Game, solution Board) error {
+	puzzle := pbnPuzzle{
+		Type:         "grid",
+		DefaultColor: "black",
+		Colors: []pbnColor{
+			{Name: "white", Char: ".", Value: "fff"},
+			{Name: "black", Char: "X", Value: "000"},
+		},
+		Clues: []pbnClues{
+			newPbnClues("columns", game.colHints, game.colColors),
+			newPbnClues("rows", game.rowHints, game.rowColors),
+		},
+	}
+	for c := ColorBlack + 1; c <= maxColor(game.rowColors, game.colColors); c++ {
+		puzzle.Colors = append(puzzle.Colors, pbnColorOf(c))
+	}
+	if solution != nil {
+		var image strings.Builder
+		image.WriteString("\n")
+		for i := range solution {
+			image.WriteString("|")
+			for _, c := range solution[i] {
+				switch {
+				case c == CellBlack:
+					image.WriteString("X")
+				case c.IsColored():
+					image.WriteString(pbnColorOf(c.Color()).Char)
+				default:
+					image.WriteString(".")
+				}
+			}
+			image.WriteString("|\n")
+		}
+		puzzle.Solutions = []pbnSolution{{Type: "goal", Image: pbnImage{image.String()}}}
+	}
+
+	if _, err := io.WriteString(w, xml.Header+"<!DOCTYPE pbn SYSTEM \"https://webpbn.com/pbn-0.3.dtd\">\n"); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(pbnPuzzleSet{Puzzles: []pbnPuzzle{puzzle}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,156 @@
+package picrosssolver_test
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	picrosssolver "github.com/inahym196/picross-solver"
+)
+
+const sampleWebpbn = `<?xml version="1.0"?>
+<!DOCTYPE pbn SYSTEM "https://webpbn.com/pbn-0.3.dtd">
+<puzzleset>
+<puzzle type="grid" defaultcolor="black">
+<source>sample</source>
+<title>corner</title>
+<color name="white" char=".">fff</color>
+<color name="black" char="X">000</color>
+<clues type="columns">
+<line><count>2</count></line>
+<line><count>1</count></line>
+<line></line>
+</clues>
+<clues type="rows">
+<line><count>2</count></line>
+<line><count>1</count></line>
+</clues>
+<solution type="goal">
+<image>
+|XX.|
+|X..|
+</image>
+</solution>
+</puzzle>
+</puzzleset>
+`
+
+func TestReadWebpbn(t *testing.T) {
+	game, solution, err := picrosssolver.ReadWebpbn(strings.NewReader(sampleWebpbn))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"##_", "#__"}
+	if !slices.Equal(solution.Print(), expected) {
+		t.Errorf("expected solution %v, got %v", expected, solution.Print())
+	}
+	if _, _, err := picrosssolver.NewSolver().ApplyMany(game); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(game.PrintBoard(), expected) {
+		t.Errorf("expected %v, got %v", expected, game.PrintBoard())
+	}
+}
+
+func TestWebpbnRoundTrip(t *testing.T) {
+	tests := []string{sampleWebpbn, colorWebpbn}
+
+	for i, input := range tests {
+		t.Run(fmt.Sprintf("case%d", i), func(t *testing.T) {
+			game, solution, err := picrosssolver.ReadWebpbn(strings.NewReader(input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var b strings.Builder
+			if err := picrosssolver.WriteWebpbn(&b, game, solution); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			game2, solution2, err := picrosssolver.ReadWebpbn(strings.NewReader(b.String()))
+			if err != nil {
+				t.Fatalf("unexpected error: %v\n%s", err, b.String())
+			}
+
+			if !slices.Equal(solution.Print(), solution2.Print()) {
+				t.Errorf("expected solution %v, got %v", solution.Print(), solution2.Print())
+			}
+			for i := range len(game.RowHints()) + len(game.ColHints()) {
+				ref := picrosssolver.LineRef{Kind: picrosssolver.LineKindRow, Index: i}
+				if i >= len(game.RowHints()) {
+					ref = picrosssolver.LineRef{Kind: picrosssolver.LineKindColumn, Index: i - len(game.RowHints())}
+				}
+				if !slices.Equal(game.LineColors(ref), game2.LineColors(ref)) {
+					t.Errorf("expected %v colors %v, got %v", ref, game.LineColors(ref), game2.LineColors(ref))
+				}
+			}
+			var b2 strings.Builder
+			picrosssolver.WriteWebpbn(&b2, game2, solution2)
+			if b.String() != b2.String() {
+				t.Errorf("expected\n%s\ngot\n%s", b.String(), b2.String())
+			}
+		})
+	}
+}
+
+const colorWebpbn = `<?xml version="1.0"?>
+<puzzleset>
+<puzzle type="grid" defaultcolor="black">
+<color name="white" char=".">fff</color>
+<color name="black" char="X">000</color>
+<color name="red" char="r">f00</color>
+<clues type="columns">
+<line><count>1</count><count color="red">1</count></line>
+<line><count color="red">2</count></line>
+</clues>
+<clues type="rows">
+<line><count>1</count><count color="red">1</count></line>
+<line><count color="red">2</count></line>
+</clues>
+<solution type="goal">
+<image>
+|Xr|
+|rr|
+</image>
+</solution>
+</puzzle>
+</puzzleset>
+`
+
+func TestReadWebpbnColor(t *testing.T) {
+	game, solution, err := picrosssolver.ReadWebpbn(strings.NewReader(colorWebpbn))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if game.IsMonochrome() {
+		t.Errorf("expected colored game")
+	}
+	expected := []string{"#1", "11"}
+	if !slices.Equal(solution.Print(), expected) {
+		t.Errorf("expected solution %v, got %v", expected, solution.Print())
+	}
+	if _, _, err := picrosssolver.NewSolver().ApplyMany(game); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(game.PrintBoard(), expected) {
+		t.Errorf("expected %v, got %v", expected, game.PrintBoard())
+	}
+}
+
+func TestReadWebpbnUnknownColor(t *testing.T) {
+	input := strings.Replace(sampleWebpbn, `<line><count>1</count></line>
+<line></line>`, `<line><count color="red">1</count></line>
+<line></line>`, 1)
+
+	_, _, err := picrosssolver.ReadWebpbn(strings.NewReader(input))
+
+	if !errors.Is(err, picrosssolver.ErrUnknownColor) {
+		t.Fatalf("expected ErrUnknownColor, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "Col[1]") {
+		t.Errorf("expected error to name Col[1], got %v", err)
+	}
+}